@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ngyewch/go-spectrogram/pkg/audio"
+	"github.com/spf13/cobra"
+	"sort"
+	"strings"
+)
+
+var (
+	formatsCmd = &cobra.Command{
+		Use:   "formats",
+		Short: "List supported input formats.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listFormats()
+		},
+	}
+)
+
+func listFormats() {
+	decoders := audio.Decoders()
+	sort.Slice(decoders, func(i, j int) bool {
+		return decoders[i].Name < decoders[j].Name
+	})
+	for _, decoder := range decoders {
+		fmt.Printf("%-8s %-24s %s\n", decoder.Name,
+			strings.Join(decoder.Extensions, " "), strings.Join(decoder.MimeTypes, " "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(formatsCmd)
+}
@@ -10,15 +10,19 @@ import (
 	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var (
 	rootCmd = &cobra.Command{
 		Use:   "spectrogram [flags] input_audio_path output_image_path",
 		Short: "spectrogram.",
-		Args:  cobra.ExactArgs(2),
+		Long: "Generates a spectrogram image from an audio file.\n\n" +
+			"Use - as input_audio_path to read from stdin, or as output_image_path to write to stdout.",
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := run(cmd, args)
 			if err != nil {
@@ -28,9 +32,17 @@ var (
 	}
 
 	channel              uint
+	channelName          string
+	channelModeName      string
+	channelWeights       []float64
 	fftSamples           uint
+	windowSamples        uint
 	overlap              uint
 	windowFunctionName   string
+	scalingName          string
+	referenceName        string
+	calibration          float64
+	floor                float64
 	minFrequency         uint
 	maxFrequency         uint
 	relativeMinFrequency float64
@@ -38,8 +50,25 @@ var (
 	relativeMinDecibels  float64
 	relativeMaxDecibels  float64
 	colorMapName         string
+	verifyMD5            bool
+	lenient              bool
+	rawFormatSpec        string
+	imageFormat          string
+	resampleRate         uint
+	resampleQualityName  string
+	startSpec            string
+	durationSpec         string
+	workers              uint
+	numMels              uint
+	melMinFrequency      float64
+	melMaxFrequency      float64
+	melScaleName         string
+	melNormalize         bool
 )
 
+// stdioPath is the path that refers to stdin for input and stdout for output.
+const stdioPath = "-"
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
@@ -61,12 +90,59 @@ func run(cmd *cobra.Command, args []string) error {
 	spectrogramOptions := spectrogram.SpectrogramOptions{
 		Channel:        channel,
 		FftSamples:     fftSamples,
-		Overlap:        &overlap,
+		WindowSamples:  windowSamples,
 		WindowFunction: windowFunction,
+		Workers:        workers,
 	}
 	renderOptions := spectrogram.RenderOptions{
 		ColorMap: colorMap,
 	}
+	scaling, err := spectrogram.ParseScaling(scalingName)
+	if err != nil {
+		return err
+	}
+	spectrogramOptions.Scaling = scaling
+	reference, err := spectrogram.ParseReference(referenceName)
+	if err != nil {
+		return err
+	}
+	spectrogramOptions.Reference = reference
+	spectrogramOptions.Calibration = calibration
+	spectrogramOptions.Floor = &floor
+	if isFlagPassed(cmd.Flags(), "overlap") {
+		spectrogramOptions.Overlap = &overlap
+	} else {
+		window := fftSamples
+		if windowSamples != 0 {
+			window = windowSamples
+		}
+		defaultOverlap := window * 3 / 4
+		spectrogramOptions.Overlap = &defaultOverlap
+	}
+	if isFlagPassed(cmd.Flags(), "channel-mode") {
+		channelMode, err := spectrogram.ParseChannelMode(channelModeName)
+		if err != nil {
+			return err
+		}
+		spectrogramOptions.ChannelMode = channelMode
+	} else if isFlagPassed(cmd.Flags(), "channel-weights") {
+		spectrogramOptions.ChannelMode = spectrogram.ChannelModeWeighted
+	}
+	spectrogramOptions.ChannelWeights = channelWeights
+	if isFlagPassed(cmd.Flags(), "start") {
+		start, err := spectrogram.ParseTime(startSpec)
+		if err != nil {
+			return err
+		}
+		spectrogramOptions.Start = &start
+	}
+	if isFlagPassed(cmd.Flags(), "duration") {
+		duration, err := spectrogram.ParseTime(durationSpec)
+		if err != nil {
+			return err
+		}
+		spectrogramOptions.Duration = &duration
+	}
 	if isFlagPassed(cmd.Flags(), "min-freq") {
 		renderOptions.MinFrequency = &minFrequency
 	}
@@ -89,33 +165,139 @@ func run(cmd *cobra.Command, args []string) error {
 	inputPath := args[0]
 	outputPath := args[1]
 
-	src, err := audio.ReadFromFile(inputPath)
+	outputFormat := strings.ToLower(imageFormat)
+	if outputFormat == "jpg" {
+		outputFormat = "jpeg"
+	} else if outputFormat == "" {
+		outputFormat = imageFormatFromPath(outputPath)
+		if outputFormat == "" {
+			return fmt.Errorf("cannot determine the image format of %s, use --format", outputPath)
+		}
+	}
+	if (outputFormat != "png") && (outputFormat != "jpeg") {
+		return fmt.Errorf("unknown image format: %s", outputFormat)
+	}
+
+	var src audio.StreamSource
+	if isFlagPassed(cmd.Flags(), "raw-format") {
+		var rawFormat *audio.RawFormat
+		rawFormat, err = audio.ParseRawFormat(rawFormatSpec)
+		if err != nil {
+			return err
+		}
+		if inputPath == stdioPath {
+			src, err = rawFormat.NewStreamFromReader(os.Stdin, audio.ReadOptions{})
+		} else {
+			src, err = audio.OpenFileStream(inputPath, audio.ReadOptions{}, rawFormat.NewStreamFromReader)
+		}
+	} else {
+		readOptions := audio.ReadOptions{
+			VerifyMD5: verifyMD5,
+			Lenient:   lenient,
+		}
+		if inputPath == stdioPath {
+			src, err = audio.OpenReaderWithOptions(os.Stdin, "", readOptions)
+		} else {
+			src, err = audio.OpenFileWithOptions(inputPath, readOptions)
+		}
+	}
 	if err != nil {
 		return err
 	}
+	defer src.Close()
 
-	spec, err := spectrogram.GenerateSpectrogram(src, spectrogramOptions)
+	if isFlagPassed(cmd.Flags(), "channel-name") {
+		channelIndex, err := src.Info().ChannelIndex(channelName)
+		if err != nil {
+			return err
+		}
+		spectrogramOptions.Channel = uint(channelIndex)
+	}
+
+	if isFlagPassed(cmd.Flags(), "resample") && (int(resampleRate) != src.Info().SampleRate) {
+		resampleQuality, err := audio.ParseResampleQuality(resampleQualityName)
+		if err != nil {
+			return err
+		}
+		resampled, err := audio.NewResampleStream(src, int(resampleRate), resampleQuality)
+		if err != nil {
+			return err
+		}
+		src = resampled
+	}
+
+	spec, err := spectrogram.GenerateSpectrogramFromStream(src, spectrogramOptions)
 	if err != nil {
 		return err
 	}
 
+	if isFlagPassed(cmd.Flags(), "mels") {
+		melScale, err := spectrogram.ParseMelScale(melScaleName)
+		if err != nil {
+			return err
+		}
+		melOptions := spectrogram.MelOptions{
+			NumMels:       numMels,
+			MinFrequency:  melMinFrequency,
+			Scale:         melScale,
+			AreaNormalize: melNormalize,
+		}
+		if isFlagPassed(cmd.Flags(), "mel-max-freq") {
+			melOptions.MaxFrequency = &melMaxFrequency
+		}
+		spec, err = spec.ToMel(melOptions)
+		if err != nil {
+			return err
+		}
+	}
+
 	img, _, err := spec.ToImage(renderOptions)
 	if err != nil {
 		return err
 	}
 
-	err = saveImageToFile(img, outputPath)
-
-	return nil
+	if outputPath == stdioPath {
+		return writeImage(os.Stdout, img, outputFormat)
+	}
+	return saveImageToFile(img, outputPath, outputFormat)
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.Flags().UintVar(&channel, "channel", 0, "Channel.")
-	rootCmd.Flags().UintVar(&fftSamples, "fft-samples", 1024, "FFT samples.")
-	rootCmd.Flags().UintVar(&overlap, "overlap", 768, "Overlap.")
+	rootCmd.Flags().StringVar(&channelName, "channel-name", "", "Channel name (e.g. FL, FR, LFE). Overrides --channel.")
+	rootCmd.Flags().StringVar(&channelModeName, "channel-mode", "single",
+		"Channel mode (single, average, weighted, mid, side, power-sum, all). single uses --channel, mid is L+R "+
+			"and side is L-R of the first two channels, all renders each channel in its own panel.")
+	rootCmd.Flags().Float64SliceVar(&channelWeights, "channel-weights", nil,
+		"Weight of each channel for the weighted channel mode (e.g. 0.7,0.3). Implies --channel-mode weighted.")
+	rootCmd.Flags().UintVar(&fftSamples, "fft-samples", 1024, "FFT samples. Need not be a power of 2.")
+	rootCmd.Flags().UintVar(&windowSamples, "window-samples", 0,
+		"Window length, zero-padded to --fft-samples. Defaults to --fft-samples.")
+	rootCmd.Flags().UintVar(&overlap, "overlap", 0, "Overlap. Defaults to 3/4 of the window length.")
 	rootCmd.Flags().StringVar(&windowFunctionName, "window-func", "hann", "Window function.")
+	rootCmd.Flags().StringVar(&scalingName, "scaling", "db",
+		"Scaling of the bins (db, magnitude, power, psd, amplitude). db is uncorrected for the window; magnitude and "+
+			"power are linear, psd is in dB/Hz and amplitude in dB.")
+	rootCmd.Flags().StringVar(&referenceName, "reference", "dbfs",
+		"Reference of the dB scalings (dbfs, re1, spl). spl uses --calibration.")
+	rootCmd.Flags().Float64Var(&calibration, "calibration", 0, "Level of a full-scale sinusoid in dB SPL.")
+	rootCmd.Flags().Float64Var(&floor, "floor", spectrogram.DefaultFloor,
+		"Lowest value of the dB scalings, so that silence does not produce -Inf.")
+	rootCmd.Flags().StringVar(&startSpec, "start", "",
+		"Start of the audio to process, in seconds (e.g. 90 or 2.5s), samples (e.g. 48000smp) or [[hh:]mm:]ss[.mmm].")
+	rootCmd.Flags().StringVar(&durationSpec, "duration", "",
+		"Duration of the audio to process, in the same format as --start.")
+	rootCmd.Flags().UintVar(&workers, "workers", 0,
+		"Number of goroutines computing the spectrogram. Defaults to the number of CPUs.")
+	rootCmd.Flags().UintVar(&numMels, "mels", 0, "Render a mel spectrogram of this many bands (e.g. 128).")
+	rootCmd.Flags().Float64Var(&melMinFrequency, "mel-min-freq", 0, "Lowest frequency of the mel filterbank.")
+	rootCmd.Flags().Float64Var(&melMaxFrequency, "mel-max-freq", 0,
+		"Highest frequency of the mel filterbank. Defaults to half the sample rate.")
+	rootCmd.Flags().StringVar(&melScaleName, "mel-scale", "slaney", "Mel scale (slaney, htk).")
+	rootCmd.Flags().BoolVar(&melNormalize, "mel-norm", true,
+		"Normalize the area of the mel filters (Slaney normalization).")
 	rootCmd.Flags().UintVar(&minFrequency, "min-freq", 0, "Min frequency.")
 	rootCmd.Flags().UintVar(&maxFrequency, "max-freq", 0, "Max frequency.")
 	rootCmd.Flags().Float64Var(&relativeMinFrequency, "relative-min-freq", 0, "Relative min frequency.")
@@ -123,6 +305,15 @@ func init() {
 	rootCmd.Flags().Float64Var(&relativeMinDecibels, "relative-min-db", 0, "Relative min decibels.")
 	rootCmd.Flags().Float64Var(&relativeMaxDecibels, "relative-max-db", 0, "Relative max decibels.")
 	rootCmd.Flags().StringVar(&colorMapName, "color-map", "inferno", "Color map.")
+	rootCmd.Flags().BoolVar(&verifyMD5, "verify-md5", false, "Verify the decoded audio against the MD5 signature.")
+	rootCmd.Flags().BoolVar(&lenient, "lenient", false, "Render the audio decoded before an error.")
+	rootCmd.Flags().StringVar(&rawFormatSpec, "raw-format", "",
+		"Read the input as headerless PCM of the specified format, encoding[le|be]:sample_rate:channels "+
+			"(e.g. s16le:48000:2). Encodings: s8, u8, s16, s24, s32, f32, f64.")
+	rootCmd.Flags().StringVar(&imageFormat, "format", "",
+		"Output image format (png, jpeg). Defaults to the output file extension, or png for stdout.")
+	rootCmd.Flags().UintVar(&resampleRate, "resample", 0, "Resample the audio to this sample rate.")
+	rootCmd.Flags().StringVar(&resampleQualityName, "resample-quality", "high", "Resample quality (low, medium, high).")
 
 	versionInfoCobra.AddVersionCmd(rootCmd, nil)
 }
@@ -141,24 +332,30 @@ func isFlagPassed(flagSet *pflag.FlagSet, name string) bool {
 	return found
 }
 
-func saveImageToFile(img image.Image, path string) error {
+// imageFormatFromPath returns the image format for the extension of path, png for stdout, or "" if unknown.
+func imageFormatFromPath(path string) string {
+	if path == stdioPath {
+		return "png"
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".png" {
+		return "png"
+	} else if (ext == ".jpg") || (ext == ".jpeg") {
+		return "jpeg"
+	}
+	return ""
+}
+
+func saveImageToFile(img image.Image, path string, format string) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	ext := filepath.Ext(path)
-	if ext == ".png" {
-		err = png.Encode(f, img)
-		if err != nil {
-			return err
-		}
-	} else if ext == ".jpg" || ext == ".jpeg" {
-		err = jpeg.Encode(f, img, nil)
-		if err != nil {
-			return err
-		}
+	err = writeImage(f, img, format)
+	if err != nil {
+		return err
 	}
 
 	err = f.Close()
@@ -168,3 +365,10 @@ func saveImageToFile(img image.Image, path string) error {
 
 	return nil
 }
+
+func writeImage(w io.Writer, img image.Image, format string) error {
+	if format == "jpeg" {
+		return jpeg.Encode(w, img, nil)
+	}
+	return png.Encode(w, img)
+}
@@ -7,12 +7,15 @@ toolchain go1.21.6
 require (
 	github.com/dim13/colormap v0.0.0-20220904085911-1f7b49defe90
 	github.com/gabriel-vasile/mimetype v1.4.3
+	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/jfreymuth/oggvorbis v1.0.5
 	github.com/mewkiz/flac v1.0.10
 	github.com/mjibson/go-dsp v0.0.0-20180508042940-11479a337f12
 	github.com/montanaflynn/stats v0.7.1
 	github.com/ngyewch/go-versioninfo v1.5.1
 	github.com/spf13/cobra v1.8.0
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/image v0.18.0
 )
 
 require (
@@ -32,6 +35,7 @@ require (
 	github.com/icza/bitio v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/jbenet/go-context v0.0.0-20150711004518-d14ea06fba99 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	github.com/kevinburke/ssh_config v1.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.8 // indirect
 	github.com/mattn/go-isatty v0.0.12 // indirect
@@ -0,0 +1,60 @@
+package aiff
+
+type Aiff struct {
+	Comm        *Comm
+	Frames      [][]float64
+	AIFC        bool     // AIFF-C file
+	Chunks      []*Chunk // all chunks in file order; only the parsed chunks have Data
+	Markers     []*Marker
+	Comments    []*Comment
+	Name        string
+	Author      string
+	Copyright   string
+	Annotations []string
+}
+
+type Comm struct {
+	NumChannels     int
+	NumSampleFrames int64
+	SampleSize      int     // bits per sample; for compressed data, the size before compression
+	SampleRate      float64 // stored as an 80-bit IEEE 754 extended precision number
+	CompressionType string  // AIFF-C only, e.g. "NONE", "sowt", "fl32"; "NONE" for AIFF
+	CompressionName string  // AIFF-C only: human-readable compression name
+}
+
+type Chunk struct {
+	ID   string
+	Size int64
+	Data []byte
+}
+
+type Marker struct {
+	ID       int
+	Position int64 // in sample frames
+	Name     string
+}
+
+type Comment struct {
+	Timestamp uint32 // seconds since January 1, 1904
+	MarkerID  int    // the marker the comment refers to, 0 for none
+	Text      string
+}
+
+// ChannelNames returns the speaker name (e.g. "FL", "FC") of each channel, following the channel orders defined by
+// the AIFF specification. Channel counts without a defined order have empty names.
+func (comm *Comm) ChannelNames() []string {
+	names := make([]string, comm.NumChannels)
+	switch comm.NumChannels {
+	case 1:
+		copy(names, []string{"FC"})
+	case 2:
+		copy(names, []string{"FL", "FR"})
+	case 3:
+		copy(names, []string{"FL", "FR", "FC"})
+	case 4:
+		copy(names, []string{"FL", "FR", "BL", "BR"})
+	case 6:
+		copy(names, []string{"FL", "FLC", "FC", "FR", "FRC", "BC"})
+	}
+	return names
+}
@@ -0,0 +1,154 @@
+package aiff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// decoderBufferFrames is the number of frames decoded at a time when reading the whole SSND chunk.
+const decoderBufferFrames = 4096
+
+// Decoder decodes the frames of the SSND chunk on demand, so that the file need not be held in memory.
+type Decoder struct {
+	aiff         *Aiff
+	aiffReader   *aiffReader
+	chunkReader  io.Reader
+	chunkSize    uint32
+	reader       io.Reader
+	sampleReader *sampleReader
+	numFrames    int64
+	buffer       []byte
+}
+
+// FileDecoder is a Decoder that owns the underlying file.
+type FileDecoder struct {
+	*Decoder
+	file *os.File
+}
+
+func OpenDecoderFromFile(f string) (*FileDecoder, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := NewDecoder(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileDecoder{
+		Decoder: decoder,
+		file:    file,
+	}, nil
+}
+
+func (fd *FileDecoder) Close() error {
+	return fd.file.Close()
+}
+
+// NewDecoder reads the header and the chunks preceding the SSND chunk of an AIFF or AIFF-C file.
+func NewDecoder(reader io.Reader) (*Decoder, error) {
+	aiffReader, err := newAiffReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	aiff := aiffReader.newAiff()
+	chunkReader, chunkSize, err := aiffReader.readChunksUntilSound(aiff, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if chunkReader == nil {
+		if aiff.Comm.NumSampleFrames > 0 {
+			return nil, errors.New(fmt.Sprintf("missing chunk %s", string(ssndChunkId)))
+		}
+		// the SSND chunk is optional when there are no sample frames
+		return aiffReader.newDecoder(aiff, io.LimitReader(reader, 0), 0), nil
+	}
+	soundReader, soundSize, err := readSoundDataHeader(chunkReader, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := aiffReader.newDecoder(aiff, soundReader, soundSize)
+	decoder.aiffReader = aiffReader
+	decoder.chunkReader = chunkReader
+	decoder.chunkSize = chunkSize
+	return decoder, nil
+}
+
+func (ar *aiffReader) newDecoder(aiff *Aiff, soundReader io.Reader, soundSize uint32) *Decoder {
+	sampleReader := newSampleReader(aiff.Comm)
+	blockAlign := int64(sampleReader.bytesPerSample * aiff.Comm.NumChannels)
+	numFrames := aiff.Comm.NumSampleFrames
+	if int64(soundSize)/blockAlign < numFrames {
+		numFrames = int64(soundSize) / blockAlign
+	}
+	return &Decoder{
+		aiff:         aiff,
+		reader:       io.LimitReader(soundReader, numFrames*blockAlign),
+		sampleReader: sampleReader,
+		numFrames:    numFrames,
+	}
+}
+
+// Aiff returns the header and the metadata chunks preceding the SSND chunk. Frames is always nil.
+func (d *Decoder) Aiff() *Aiff {
+	return d.aiff
+}
+
+// ReadTrailingChunks skips the rest of the SSND chunk and parses the metadata chunks after it, such as markers,
+// into Aiff.
+func (d *Decoder) ReadTrailingChunks() error {
+	if d.aiffReader == nil {
+		return nil
+	}
+	err := d.aiffReader.skipChunk(d.chunkReader, d.chunkSize)
+	if err != nil {
+		return err
+	}
+	_, _, err = d.aiffReader.readChunksUntilSound(d.aiff, false, true)
+	d.aiffReader = nil
+	return err
+}
+
+// NumFrames returns the number of frames, according to the COMM chunk, limited by the size of the SSND chunk.
+func (d *Decoder) NumFrames() int64 {
+	return d.numFrames
+}
+
+// ReadFrames decodes up to len(buffer) / NumChannels frames into buffer, interleaved. Returns the number of frames
+// decoded, or io.EOF when there are no more frames. A truncated trailing frame is dropped.
+func (d *Decoder) ReadFrames(buffer []float64) (int, error) {
+	numChannels := d.aiff.Comm.NumChannels
+	bytesPerSample := d.sampleReader.bytesPerSample
+	blockAlign := numChannels * bytesPerSample
+	numFrames := len(buffer) / numChannels
+	size := numFrames * blockAlign
+	if len(d.buffer) < size {
+		d.buffer = make([]byte, size)
+	}
+
+	n, err := io.ReadFull(d.reader, d.buffer[:size])
+	numFrames = n / blockAlign
+	if (err == io.EOF) || (err == io.ErrUnexpectedEOF) {
+		if numFrames == 0 {
+			return 0, io.EOF
+		}
+		err = nil
+	}
+
+	for i := 0; i < numFrames; i++ {
+		frame := d.buffer[i*blockAlign : (i+1)*blockAlign]
+		for j := 0; j < numChannels; j++ {
+			start := j * bytesPerSample
+			buffer[i*numChannels+j] = d.sampleReader.readSample(frame[start : start+bytesPerSample])
+		}
+	}
+
+	return numFrames, err
+}
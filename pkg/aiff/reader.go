@@ -0,0 +1,532 @@
+package aiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ngyewch/go-spectrogram/pkg/wave"
+	"io"
+	"math"
+	"os"
+)
+
+// AIFF-C compression types.
+const (
+	CompressionNone      = "NONE" // big-endian signed integer PCM
+	CompressionTwos      = "twos" // big-endian signed integer PCM
+	CompressionSowt      = "sowt" // little-endian signed integer PCM
+	CompressionRaw       = "raw " // 8-bit unsigned integer PCM
+	CompressionIn24      = "in24" // 24-bit big-endian signed integer PCM
+	CompressionIn32      = "in32" // 32-bit big-endian signed integer PCM
+	CompressionFloat32   = "fl32" // 32-bit big-endian IEEE float
+	CompressionFloat32Uc = "FL32"
+	CompressionFloat64   = "fl64" // 64-bit big-endian IEEE float
+	CompressionFloat64Uc = "FL64"
+	CompressionMuLaw     = "ulaw" // G.711 µ-law
+	CompressionMuLawUc   = "ULAW"
+	CompressionALaw      = "alaw" // G.711 A-law
+	CompressionALawUc    = "ALAW"
+)
+
+// maxMetadataChunkSize is the largest parsed chunk that is read into memory (16 MiB). Larger COMM chunks are
+// rejected; larger metadata chunks are skipped, like unknown chunks.
+const maxMetadataChunkSize = 1 << 24
+
+var (
+	formChunkId  = []byte("FORM")
+	aiffFormType = []byte("AIFF")
+	aifcFormType = []byte("AIFC")
+	commChunkId  = []byte("COMM")
+	ssndChunkId  = []byte("SSND")
+	markChunkId  = []byte("MARK")
+	comtChunkId  = []byte("COMT")
+	nameChunkId  = []byte("NAME")
+	authChunkId  = []byte("AUTH")
+	copyChunkId  = []byte("(c) ")
+	annoChunkId  = []byte("ANNO")
+)
+
+type aiffReader struct {
+	reader io.Reader
+	aifc   bool
+}
+
+type sampleReader struct {
+	compressionType string
+	byteOrder       binary.ByteOrder
+	bytesPerSample  int
+	divisor         float64
+}
+
+func ReadAiffFromFile(f string, headerOnly bool) (*Aiff, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadAiffFromReader(file, headerOnly)
+}
+
+func ReadAiffFromReader(reader io.Reader, headerOnly bool) (*Aiff, error) {
+	aiffReader, err := newAiffReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return aiffReader.readAiff(headerOnly)
+}
+
+// newAiffReader reads the FORM header of an AIFF or AIFF-C file.
+func newAiffReader(reader io.Reader) (*aiffReader, error) {
+	chunkId, err := readBytes(reader, 4)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Compare(chunkId, formChunkId) != 0 {
+		return nil, errors.New(fmt.Sprintf("unknown chunk ID: %s", string(chunkId)))
+	}
+
+	var chunkSize uint32
+	err = binary.Read(reader, binary.BigEndian, &chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	formReader := io.LimitReader(reader, int64(chunkSize))
+
+	formType, err := readBytes(formReader, 4)
+	if err != nil {
+		return nil, err
+	}
+	aifc := false
+	if bytes.Compare(formType, aifcFormType) == 0 {
+		aifc = true
+	} else if bytes.Compare(formType, aiffFormType) != 0 {
+		return nil, errors.New(fmt.Sprintf("unknown form type: %s", string(formType)))
+	}
+
+	return &aiffReader{
+		reader: formReader,
+		aifc:   aifc,
+	}, nil
+}
+
+func (ar *aiffReader) newAiff() *Aiff {
+	return &Aiff{
+		AIFC: ar.aifc,
+	}
+}
+
+// readAiff walks the remaining chunks, decoding the SSND chunk and parsing known metadata chunks.
+func (ar *aiffReader) readAiff(headerOnly bool) (*Aiff, error) {
+	aiff := ar.newAiff()
+	chunkReader, chunkSize, err := ar.readChunksUntilSound(aiff, headerOnly, false)
+	if err != nil {
+		return nil, err
+	}
+	if chunkReader == nil {
+		if !headerOnly && (aiff.Comm.NumSampleFrames > 0) {
+			return nil, errors.New(fmt.Sprintf("missing chunk %s", string(ssndChunkId)))
+		}
+		return aiff, nil
+	}
+
+	if !headerOnly {
+		soundReader, soundSize, err := readSoundDataHeader(chunkReader, chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		decoder := ar.newDecoder(aiff, soundReader, soundSize)
+		frames := make([][]float64, 0)
+		buffer := make([]float64, decoderBufferFrames*aiff.Comm.NumChannels)
+		for {
+			n, err := decoder.ReadFrames(buffer)
+			for i := 0; i < n; i++ {
+				frame := make([]float64, aiff.Comm.NumChannels)
+				copy(frame, buffer[i*aiff.Comm.NumChannels:])
+				frames = append(frames, frame)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		aiff.Frames = frames
+	}
+	err = ar.skipChunk(chunkReader, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// metadata chunks after the SSND chunk
+	_, _, err = ar.readChunksUntilSound(aiff, headerOnly, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return aiff, nil
+}
+
+// readChunksUntilSound parses chunks into aiff until the SSND chunk is found, returning a reader limited to its
+// contents and its size. Returns a nil reader if the end of the file is reached. Chunks that are not parsed are
+// skipped without being read into memory. If afterSound is set, further SSND chunks are skipped and trailing garbage,
+// including a truncated chunk, is tolerated.
+func (ar *aiffReader) readChunksUntilSound(aiff *Aiff, headerOnly bool, afterSound bool) (io.Reader, uint32, error) {
+	for {
+		chunkId, chunkSize, err := ar.readChunkIdAndSize()
+		if (err == io.EOF) || ((err == io.ErrUnexpectedEOF) && afterSound) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		chunk := &Chunk{
+			ID:   string(chunkId),
+			Size: int64(chunkSize),
+		}
+		chunkReader := io.LimitReader(ar.reader, int64(chunkSize))
+		if isParsedChunk(chunkId) {
+			if chunkSize <= maxMetadataChunkSize {
+				chunk.Data, err = readBytes(chunkReader, int(chunkSize))
+				if ((err == io.EOF) || (err == io.ErrUnexpectedEOF)) && afterSound {
+					// a truncated trailing chunk
+					break
+				}
+				if err != nil {
+					return nil, 0, err
+				}
+			} else if bytes.Compare(chunkId, commChunkId) == 0 {
+				return nil, 0, errors.New(fmt.Sprintf("%s chunk size too big", string(chunkId)))
+			}
+		}
+		aiff.Chunks = append(aiff.Chunks, chunk)
+
+		if bytes.Compare(chunkId, ssndChunkId) == 0 {
+			if aiff.Comm == nil {
+				return nil, 0, errors.New(fmt.Sprintf("expected chunk %s, found chunk %s",
+					string(commChunkId), string(chunkId)))
+			}
+			if !afterSound {
+				return chunkReader, chunkSize, nil
+			}
+		} else if chunk.Data != nil {
+			err = ar.parseChunk(aiff, chunk, headerOnly)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		err = ar.skipChunk(chunkReader, chunkSize)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if aiff.Comm == nil {
+		return nil, 0, errors.New(fmt.Sprintf("missing chunk %s", string(commChunkId)))
+	}
+
+	return nil, 0, nil
+}
+
+// isParsedChunk returns whether a chunk is read into memory and parsed by parseChunk.
+func isParsedChunk(chunkId []byte) bool {
+	for _, id := range [][]byte{commChunkId, markChunkId, comtChunkId, nameChunkId, authChunkId, copyChunkId,
+		annoChunkId} {
+		if bytes.Compare(chunkId, id) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseChunk parses the known chunks into aiff. Malformed MARK and COMT chunks are tolerated, and are only in
+// aiff.Chunks.
+func (ar *aiffReader) parseChunk(aiff *Aiff, chunk *Chunk, headerOnly bool) error {
+	switch chunk.ID {
+	case string(commChunkId):
+		comm, err := ar.parseComm(chunk.Data)
+		if err != nil {
+			return err
+		}
+		if !headerOnly {
+			err = validateComm(comm)
+			if err != nil {
+				return err
+			}
+		}
+		aiff.Comm = comm
+	case string(markChunkId):
+		markers, err := parseMarkers(chunk.Data)
+		if err != nil {
+			break
+		}
+		aiff.Markers = markers
+	case string(comtChunkId):
+		comments, err := parseComments(chunk.Data)
+		if err != nil {
+			break
+		}
+		aiff.Comments = comments
+	case string(nameChunkId):
+		aiff.Name = parseString(chunk.Data)
+	case string(authChunkId):
+		aiff.Author = parseString(chunk.Data)
+	case string(copyChunkId):
+		aiff.Copyright = parseString(chunk.Data)
+	case string(annoChunkId):
+		aiff.Annotations = append(aiff.Annotations, parseString(chunk.Data))
+	}
+	return nil
+}
+
+// skipChunk discards the unread remainder of a chunk, and its padding to 2 bytes.
+func (ar *aiffReader) skipChunk(chunkReader io.Reader, chunkSize uint32) error {
+	_, err := io.Copy(io.Discard, chunkReader)
+	if err != nil {
+		return err
+	}
+	if (chunkSize % 2) != 0 {
+		// the padding is frequently missing from the last chunk
+		_, err = readBytes(ar.reader, 1)
+		if (err != nil) && (err != io.EOF) && (err != io.ErrUnexpectedEOF) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ar *aiffReader) readChunkIdAndSize() ([]byte, uint32, error) {
+	chunkId, err := readBytes(ar.reader, 4)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var chunkSize uint32
+	err = binary.Read(ar.reader, binary.BigEndian, &chunkSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return chunkId, chunkSize, nil
+}
+
+func (ar *aiffReader) parseComm(data []byte) (*Comm, error) {
+	if len(data) < 18 {
+		return nil, errors.New(fmt.Sprintf("%s chunk too short", string(commChunkId)))
+	}
+	sampleRate, err := parseExtended(data[8:18])
+	if err != nil {
+		return nil, err
+	}
+	comm := &Comm{
+		NumChannels:     int(binary.BigEndian.Uint16(data[0:2])),
+		NumSampleFrames: int64(binary.BigEndian.Uint32(data[2:6])),
+		SampleSize:      int(binary.BigEndian.Uint16(data[6:8])),
+		SampleRate:      sampleRate,
+		CompressionType: CompressionNone,
+	}
+	if ar.aifc {
+		if len(data) < 22 {
+			return nil, errors.New(fmt.Sprintf("%s chunk too short", string(commChunkId)))
+		}
+		comm.CompressionType = string(data[18:22])
+		comm.CompressionName, _ = parsePString(data[22:])
+	}
+	return comm, nil
+}
+
+func validateComm(comm *Comm) error {
+	if comm.NumChannels < 1 {
+		return errors.New(fmt.Sprintf("invalid number of channels: %d", comm.NumChannels))
+	}
+	if (comm.SampleRate <= 0) || math.IsInf(comm.SampleRate, 0) || math.IsNaN(comm.SampleRate) {
+		return errors.New(fmt.Sprintf("invalid sample rate: %g", comm.SampleRate))
+	}
+	switch comm.CompressionType {
+	case CompressionNone, CompressionTwos, CompressionSowt:
+		if (comm.SampleSize < 1) || (comm.SampleSize > 32) {
+			return errors.New(fmt.Sprintf("unsupported sample size: %d", comm.SampleSize))
+		}
+	case CompressionRaw, CompressionIn24, CompressionIn32, CompressionFloat32, CompressionFloat32Uc,
+		CompressionFloat64, CompressionFloat64Uc, CompressionMuLaw, CompressionMuLawUc, CompressionALaw,
+		CompressionALawUc:
+		break
+	default:
+		return errors.New(fmt.Sprintf("unsupported compression type: %s", comm.CompressionType))
+	}
+	return nil
+}
+
+// readSoundDataHeader reads the offset and block size at the start of the SSND chunk, and skips to the first sample.
+func readSoundDataHeader(chunkReader io.Reader, chunkSize uint32) (io.Reader, uint32, error) {
+	var header struct {
+		Offset    uint32
+		BlockSize uint32
+	}
+	err := binary.Read(chunkReader, binary.BigEndian, &header)
+	if err != nil {
+		return nil, 0, err
+	}
+	if (chunkSize < 8) || (header.Offset > chunkSize-8) {
+		return nil, 0, errors.New(fmt.Sprintf("invalid %s offset: %d", string(ssndChunkId), header.Offset))
+	}
+	_, err = io.CopyN(io.Discard, chunkReader, int64(header.Offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	return chunkReader, chunkSize - 8 - header.Offset, nil
+}
+
+// parseExtended converts an 80-bit IEEE 754 extended precision number, as used for the sample rate.
+func parseExtended(b []byte) (float64, error) {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7fff)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0x7fff {
+		return 0, errors.New("sample rate is infinite or NaN")
+	}
+	value := math.Ldexp(float64(mantissa), exponent-16383-63)
+	if (b[0] & 0x80) != 0 {
+		value = -value
+	}
+	return value, nil
+}
+
+func parseMarkers(data []byte) ([]*Marker, error) {
+	if len(data) < 2 {
+		return nil, errors.New(fmt.Sprintf("%s chunk too short", string(markChunkId)))
+	}
+	numMarkers := int(binary.BigEndian.Uint16(data[0:2]))
+	markers := make([]*Marker, 0, numMarkers)
+	offset := 2
+	for i := 0; i < numMarkers; i++ {
+		if len(data) < offset+7 {
+			return nil, errors.New(fmt.Sprintf("%s chunk too short", string(markChunkId)))
+		}
+		name, size := parsePString(data[offset+6:])
+		markers = append(markers, &Marker{
+			ID:       int(int16(binary.BigEndian.Uint16(data[offset : offset+2]))),
+			Position: int64(binary.BigEndian.Uint32(data[offset+2 : offset+6])),
+			Name:     name,
+		})
+		offset += 6 + size
+	}
+	return markers, nil
+}
+
+func parseComments(data []byte) ([]*Comment, error) {
+	if len(data) < 2 {
+		return nil, errors.New(fmt.Sprintf("%s chunk too short", string(comtChunkId)))
+	}
+	numComments := int(binary.BigEndian.Uint16(data[0:2]))
+	comments := make([]*Comment, 0, numComments)
+	offset := 2
+	for i := 0; i < numComments; i++ {
+		if len(data) < offset+8 {
+			return nil, errors.New(fmt.Sprintf("%s chunk too short", string(comtChunkId)))
+		}
+		count := int(binary.BigEndian.Uint16(data[offset+6 : offset+8]))
+		if len(data) < offset+8+count {
+			return nil, errors.New(fmt.Sprintf("%s chunk too short", string(comtChunkId)))
+		}
+		comments = append(comments, &Comment{
+			Timestamp: binary.BigEndian.Uint32(data[offset : offset+4]),
+			MarkerID:  int(int16(binary.BigEndian.Uint16(data[offset+4 : offset+6]))),
+			Text:      string(data[offset+8 : offset+8+count]),
+		})
+		// the text is padded to an even size
+		offset += 8 + count + count%2
+	}
+	return comments, nil
+}
+
+// parsePString parses a Pascal-style string, returning it and its size including the count byte and padding.
+func parsePString(data []byte) (string, int) {
+	if len(data) == 0 {
+		return "", 0
+	}
+	count := int(data[0])
+	size := 1 + count
+	if (size % 2) != 0 {
+		size++
+	}
+	end := 1 + count
+	if end > len(data) {
+		end = len(data)
+	}
+	return string(data[1:end]), size
+}
+
+// parseString parses the text of a NAME, AUTH, (c) or ANNO chunk, which may be NUL padded.
+func parseString(data []byte) string {
+	return string(bytes.TrimRight(data, "\x00"))
+}
+
+func newSampleReader(comm *Comm) *sampleReader {
+	sr := &sampleReader{
+		compressionType: comm.CompressionType,
+		byteOrder:       binary.BigEndian,
+		bytesPerSample:  (comm.SampleSize + 7) / 8,
+	}
+	switch comm.CompressionType {
+	case CompressionSowt:
+		sr.byteOrder = binary.LittleEndian
+	case CompressionRaw:
+		sr.bytesPerSample = 1
+	case CompressionIn24:
+		sr.bytesPerSample = 3
+	case CompressionIn32, CompressionFloat32, CompressionFloat32Uc:
+		sr.bytesPerSample = 4
+	case CompressionFloat64, CompressionFloat64Uc:
+		sr.bytesPerSample = 8
+	case CompressionMuLaw, CompressionMuLawUc, CompressionALaw, CompressionALawUc:
+		// the sample size is that of the expanded samples
+		sr.bytesPerSample = 1
+	}
+	// samples are left-justified within their bytes, so scale by the full width
+	sr.divisor = math.Ldexp(1, sr.bytesPerSample*8-1)
+	return sr
+}
+
+func (sr *sampleReader) readSample(data []byte) float64 {
+	switch sr.compressionType {
+	case CompressionFloat32, CompressionFloat32Uc:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case CompressionFloat64, CompressionFloat64Uc:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	case CompressionMuLaw, CompressionMuLawUc:
+		return float64(wave.DecodeMuLaw(data[0])) / 32768
+	case CompressionALaw, CompressionALawUc:
+		return float64(wave.DecodeALaw(data[0])) / 32768
+	case CompressionRaw:
+		return (float64(data[0]) - 128) / 128
+	}
+
+	var v int64
+	if sr.byteOrder == binary.BigEndian {
+		for _, b := range data {
+			v = (v << 8) | int64(b)
+		}
+	} else {
+		for i := len(data) - 1; i >= 0; i-- {
+			v = (v << 8) | int64(data[i])
+		}
+	}
+	// sign extend
+	shift := 64 - 8*len(data)
+	v = (v << shift) >> shift
+	return float64(v) / sr.divisor
+}
+
+func readBytes(reader io.Reader, size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(reader, data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,277 @@
+package aiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// aiffChunk returns a chunk of an AIFF file, padded to 2 bytes.
+func aiffChunk(id string, data []byte) []byte {
+	chunk := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 != 0 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// extended returns a positive number as an 80-bit IEEE 754 extended precision number.
+func extended(value float64) []byte {
+	frac, exp := math.Frexp(value)
+	b := binary.BigEndian.AppendUint16(nil, uint16(16383+exp-1))
+	return binary.BigEndian.AppendUint64(b, uint64(math.Ldexp(frac, 64)))
+}
+
+// commChunk returns the COMM chunk of an AIFF file, or of an AIFF-C file if compressionType is not empty.
+func commChunk(numChannels int, numSampleFrames int, sampleSize int, sampleRate float64,
+	compressionType string) []byte {
+	data := binary.BigEndian.AppendUint16(nil, uint16(numChannels))
+	data = binary.BigEndian.AppendUint32(data, uint32(numSampleFrames))
+	data = binary.BigEndian.AppendUint16(data, uint16(sampleSize))
+	data = append(data, extended(sampleRate)...)
+	if compressionType != "" {
+		data = append(data, compressionType...)
+		data = append(data, 3, 'a', 'b', 'c')
+	}
+	return aiffChunk("COMM", data)
+}
+
+// ssndChunk returns the SSND chunk of an AIFF file, with offset bytes before the samples.
+func ssndChunk(offset int, samples []byte) []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(offset))
+	data = binary.BigEndian.AppendUint32(data, 0)
+	data = append(data, make([]byte, offset)...)
+	return aiffChunk("SSND", append(data, samples...))
+}
+
+// aiffFile returns an AIFF or AIFF-C file of the chunks.
+func aiffFile(formType string, chunks ...[]byte) []byte {
+	body := []byte(formType)
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	return aiffChunk("FORM", body)
+}
+
+// readFrames decodes an AIFF file, returning its frames interleaved.
+func readFrames(t *testing.T, file []byte) []float64 {
+	aiff, err := ReadAiffFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := make([]float64, 0)
+	for _, frame := range aiff.Frames {
+		frames = append(frames, frame...)
+	}
+	return frames
+}
+
+// compareSamples compares the samples with the expected values.
+func compareSamples(t *testing.T, name string, found []float64, expected []float64) {
+	if len(found) != len(expected) {
+		t.Errorf("%s: expected %d samples, found %d", name, len(expected), len(found))
+		return
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Errorf("%s: sample %d: expected %g, found %g", name, i, expected[i], found[i])
+		}
+	}
+}
+
+func TestParseExtended(t *testing.T) {
+	tests := []struct {
+		b        []byte
+		expected float64
+	}{
+		{[]byte{0x40, 0x0e, 0xac, 0x44, 0, 0, 0, 0, 0, 0}, 44100},
+		{[]byte{0x40, 0x0e, 0xbb, 0x80, 0, 0, 0, 0, 0, 0}, 48000},
+		{[]byte{0x40, 0x0b, 0xfa, 0x00, 0, 0, 0, 0, 0, 0}, 8000},
+		{[]byte{0x40, 0x0d, 0xac, 0x44, 0, 0, 0, 0, 0, 0}, 22050},
+		{[]byte{0x3f, 0xfe, 0x80, 0x00, 0, 0, 0, 0, 0, 0}, 0.5},
+		{[]byte{0xc0, 0x00, 0x80, 0x00, 0, 0, 0, 0, 0, 0}, -2},
+		{extended(44100 / 1.001), 44100 / 1.001},
+		{make([]byte, 10), 0},
+	}
+	for _, test := range tests {
+		found, err := parseExtended(test.b)
+		if err != nil {
+			t.Errorf("%x: %v", test.b, err)
+			continue
+		}
+		if found != test.expected {
+			t.Errorf("%x: expected %g, found %g", test.b, test.expected, found)
+		}
+	}
+
+	_, err := parseExtended([]byte{0x7f, 0xff, 0x80, 0, 0, 0, 0, 0, 0, 0})
+	if err == nil {
+		t.Errorf("expected an error for an infinite sample rate")
+	}
+}
+
+func TestReadAiff(t *testing.T) {
+	samples := binary.BigEndian.AppendUint16(nil, 0x7fff)
+	samples = binary.BigEndian.AppendUint16(samples, 0x8000)
+	samples = binary.BigEndian.AppendUint16(samples, 0x0001)
+	samples = binary.BigEndian.AppendUint16(samples, 0xfffe)
+	mark := binary.BigEndian.AppendUint16(nil, 2)
+	mark = append(mark, 0, 1, 0, 0, 0, 0, 5, 'i', 'n', 't', 'r', 'o')
+	mark = append(mark, 0, 2, 0, 0, 0, 1, 2, 'e', 'n', 0)
+	file := aiffFile("AIFF",
+		commChunk(2, 2, 16, 44100, ""),
+		aiffChunk("NAME", []byte("Name\x00")),
+		// a sound offset and a chunk after the SSND chunk
+		ssndChunk(4, samples),
+		aiffChunk("MARK", mark),
+		aiffChunk("ANNO", []byte("One")),
+		aiffChunk("ANNO", []byte("Two")),
+	)
+
+	aiff, err := ReadAiffFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comm := aiff.Comm
+	if aiff.AIFC || (comm.NumChannels != 2) || (comm.NumSampleFrames != 2) || (comm.SampleSize != 16) ||
+		(comm.SampleRate != 44100) || (comm.CompressionType != CompressionNone) {
+		t.Errorf("unexpected COMM chunk: %+v", comm)
+	}
+	frames := make([]float64, 0)
+	for _, frame := range aiff.Frames {
+		frames = append(frames, frame...)
+	}
+	compareSamples(t, "16-bit PCM", frames, []float64{32767.0 / 32768, -1, 1.0 / 32768, -2.0 / 32768})
+	if (len(aiff.Markers) != 2) || (*aiff.Markers[0] != Marker{ID: 1, Position: 0, Name: "intro"}) ||
+		(*aiff.Markers[1] != Marker{ID: 2, Position: 1, Name: "en"}) {
+		t.Errorf("unexpected markers: %+v", aiff.Markers)
+	}
+	if aiff.Name != "Name" {
+		t.Errorf("expected the name %q, found %q", "Name", aiff.Name)
+	}
+	if (len(aiff.Annotations) != 2) || (aiff.Annotations[0] != "One") || (aiff.Annotations[1] != "Two") {
+		t.Errorf("expected the annotations One and Two, found %q", aiff.Annotations)
+	}
+
+	// the decoder reads the chunks after the SSND chunk on request
+	decoder, err := NewDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (decoder.NumFrames() != 2) || (decoder.Aiff().Name != "Name") || (len(decoder.Aiff().Markers) != 0) {
+		t.Errorf("unexpected decoder header: %d frames, %+v", decoder.NumFrames(), decoder.Aiff())
+	}
+	err = decoder.ReadTrailingChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoder.Aiff().Markers) != 2 {
+		t.Errorf("expected 2 markers after the SSND chunk, found %d", len(decoder.Aiff().Markers))
+	}
+
+	// 12-bit samples are left-justified in 2 bytes; the frame count is limited by the size of the SSND chunk
+	samples = binary.BigEndian.AppendUint16(nil, 0x7ff0)
+	samples = binary.BigEndian.AppendUint16(samples, 0xfff0)
+	compareSamples(t, "12-bit PCM", readFrames(t, aiffFile("AIFF", commChunk(1, 3, 12, 8000, ""),
+		ssndChunk(0, samples))), []float64{32752.0 / 32768, -16.0 / 32768})
+}
+
+func TestReadAiffMetadata(t *testing.T) {
+	comt := binary.BigEndian.AppendUint16(nil, 2)
+	comt = binary.BigEndian.AppendUint32(comt, 3600)
+	comt = append(comt, 0, 1, 0, 3, 'o', 'n', 'e', 0)
+	comt = binary.BigEndian.AppendUint32(comt, 7200)
+	comt = append(comt, 0, 0, 0, 2, 'n', 'o')
+	file := aiffFile("AIFF",
+		commChunk(1, 1, 16, 8000, ""),
+		aiffChunk("COMT", comt),
+		aiffChunk("APPL", []byte{1, 2, 3}),
+		ssndChunk(0, []byte{0x40, 0x00}),
+		aiffChunk("NAME", []byte("Name")),
+	)
+
+	aiff, err := ReadAiffFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(aiff.Comments) != 2) || (*aiff.Comments[0] != Comment{Timestamp: 3600, MarkerID: 1, Text: "one"}) ||
+		(*aiff.Comments[1] != Comment{Timestamp: 7200, MarkerID: 0, Text: "no"}) {
+		t.Errorf("unexpected comments: %+v", aiff.Comments)
+	}
+	// unknown chunks are skipped
+	if (len(aiff.Chunks) != 5) || (aiff.Chunks[2].Data != nil) || (aiff.Chunks[2].Size != 3) {
+		t.Errorf("unexpected chunks: %+v", aiff.Chunks)
+	}
+
+	// a truncated chunk after the SSND chunk
+	aiff, err = ReadAiffFromReader(bytes.NewReader(file[:len(file)-2]), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(aiff.Chunks) != 4) || (aiff.Name != "") || (len(aiff.Frames) != 1) {
+		t.Errorf("expected 4 chunks and 1 frame, found %d and %d", len(aiff.Chunks), len(aiff.Frames))
+	}
+
+	// malformed MARK and COMT chunks are only in Chunks
+	file = aiffFile("AIFF",
+		commChunk(1, 1, 16, 8000, ""),
+		aiffChunk("MARK", []byte{0, 1, 0, 1}),
+		aiffChunk("COMT", []byte{0, 1, 0, 0, 0, 0, 0, 0, 0, 5, 'a'}),
+		ssndChunk(0, []byte{0x40, 0x00}),
+	)
+	aiff, err = ReadAiffFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (aiff.Markers != nil) || (aiff.Comments != nil) || (len(aiff.Chunks) != 4) || (aiff.Chunks[1].Data == nil) {
+		t.Errorf("expected the raw MARK and COMT chunks only, found %+v", aiff)
+	}
+}
+
+func TestReadAifc(t *testing.T) {
+	float32BE := binary.BigEndian.AppendUint32(nil, math.Float32bits(0.25))
+	float32BE = binary.BigEndian.AppendUint32(float32BE, math.Float32bits(-1.5))
+	float64BE := binary.BigEndian.AppendUint64(nil, math.Float64bits(0.1))
+	float64BE = binary.BigEndian.AppendUint64(float64BE, math.Float64bits(-3))
+	tests := []struct {
+		compressionType string
+		sampleSize      int
+		data            []byte
+		expected        []float64
+	}{
+		{CompressionNone, 16, []byte{0x40, 0x00, 0xc0, 0x00}, []float64{0.5, -0.5}},
+		{CompressionTwos, 8, []byte{0x40, 0x80}, []float64{0.5, -1}},
+		{CompressionSowt, 16, []byte{0x00, 0x40, 0x00, 0xc0}, []float64{0.5, -0.5}},
+		{CompressionSowt, 24, []byte{0x00, 0x00, 0x40, 0xff, 0xff, 0xff}, []float64{0.5, -1.0 / 8388608}},
+		{CompressionRaw, 8, []byte{0xc0, 0x00}, []float64{0.5, -1}},
+		{CompressionIn24, 24, []byte{0x40, 0x00, 0x00, 0x80, 0x00, 0x00}, []float64{0.5, -1}},
+		{CompressionIn32, 32, []byte{0x40, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}, []float64{0.5, -1.0 / 2147483648}},
+		{CompressionFloat32, 32, float32BE, []float64{0.25, -1.5}},
+		{CompressionFloat32Uc, 32, float32BE, []float64{0.25, -1.5}},
+		{CompressionFloat64, 64, float64BE, []float64{0.1, -3}},
+		// the sample size of G.711 is that of the expanded samples
+		{CompressionMuLaw, 16, []byte{0x80, 0x7f}, []float64{32124.0 / 32768, 0}},
+		{CompressionALawUc, 16, []byte{0xd5, 0x2a}, []float64{8.0 / 32768, -32256.0 / 32768}},
+	}
+	for _, test := range tests {
+		file := aiffFile("AIFC", commChunk(1, 2, test.sampleSize, 8000, test.compressionType),
+			ssndChunk(0, test.data))
+		aiff, err := ReadAiffFromReader(bytes.NewReader(file), false)
+		if err != nil {
+			t.Errorf("%s: %v", test.compressionType, err)
+			continue
+		}
+		if !aiff.AIFC || (aiff.Comm.CompressionType != test.compressionType) || (aiff.Comm.CompressionName != "abc") {
+			t.Errorf("%s: unexpected COMM chunk: %+v", test.compressionType, aiff.Comm)
+		}
+		compareSamples(t, test.compressionType, readFrames(t, file), test.expected)
+	}
+
+	_, err := ReadAiffFromReader(bytes.NewReader(aiffFile("AIFC", commChunk(1, 2, 16, 8000, "ima4"),
+		ssndChunk(0, make([]byte, 4)))), false)
+	if err == nil {
+		t.Errorf("expected an error for an unsupported compression type")
+	}
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"github.com/ngyewch/go-spectrogram/pkg/aiff"
+	"io"
+	"math"
+)
+
+func init() {
+	RegisterDecoder(&Decoder{
+		Name:       "aiff",
+		MimeTypes:  []string{"audio/aiff"},
+		Extensions: []string{".aif", ".aiff", ".aifc"},
+		Detect:     isAIFF,
+		Read:       sourceReader(ReadAIFFFromReader),
+		Open:       streamOpener(NewAIFFStreamFromReader),
+	})
+}
+
+type AIFFSource struct {
+	aiff   *aiff.Aiff
+	buffer *PlanarBuffer
+}
+
+type AIFFStream struct {
+	decoder *aiff.Decoder
+}
+
+func ReadAIFFFromReader(reader io.Reader, options ReadOptions) (*AIFFSource, error) {
+	stream, err := NewAIFFStreamFromReader(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readAll(stream, options.SampleType)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.decoder.ReadTrailingChunks()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AIFFSource{
+		aiff:   stream.decoder.Aiff(),
+		buffer: buffer,
+	}, nil
+}
+
+func (aiffSource *AIFFSource) Info() Info {
+	return aiffInfo(aiffSource.aiff.Comm, int64(aiffSource.buffer.NumFrames()))
+}
+
+// Aiff returns the header and the metadata chunks preceding the SSND chunk.
+func (aiffSource *AIFFSource) Aiff() *aiff.Aiff {
+	return aiffSource.aiff
+}
+
+func (aiffSource *AIFFSource) Frames() [][]float64 {
+	return aiffSource.buffer.Frames()
+}
+
+func (aiffSource *AIFFSource) Buffer() *PlanarBuffer {
+	return aiffSource.buffer
+}
+
+func NewAIFFStreamFromReader(reader io.Reader, options ReadOptions) (*AIFFStream, error) {
+	decoder, err := aiff.NewDecoder(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &AIFFStream{
+		decoder: decoder,
+	}, nil
+}
+
+func (aiffStream *AIFFStream) Info() Info {
+	return aiffInfo(aiffStream.decoder.Aiff().Comm, aiffStream.decoder.NumFrames())
+}
+
+func (aiffStream *AIFFStream) ReadFrames(buffer []float64) (int, error) {
+	return aiffStream.decoder.ReadFrames(buffer)
+}
+
+func (aiffStream *AIFFStream) Close() error {
+	return nil
+}
+
+func aiffInfo(comm *aiff.Comm, numFrames int64) Info {
+	return Info{
+		NumChannels:   comm.NumChannels,
+		SampleRate:    int(math.Round(comm.SampleRate)),
+		BitsPerSample: comm.SampleSize,
+		ChannelNames:  comm.ChannelNames(),
+		NumFrames:     numFrames,
+	}
+}
+
+// isAIFF matches AIFF and AIFF-C files.
+func isAIFF(header []byte) bool {
+	return (len(header) >= 12) && (string(header[0:4]) == "FORM") &&
+		((string(header[8:12]) == "AIFF") || (string(header[8:12]) == "AIFC"))
+}
@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// wavFile returns a 16-bit PCM WAVE file of the interleaved samples. dataSize overrides the size of the data sub-chunk
+// if not negative.
+func wavFile(numChannels int, sampleRate int, samples []int16, dataSize int64) []byte {
+	var buffer bytes.Buffer
+	data := new(bytes.Buffer)
+	_ = binary.Write(data, binary.LittleEndian, samples)
+	size := uint32(data.Len())
+	if dataSize >= 0 {
+		size = uint32(dataSize)
+	}
+
+	buffer.WriteString("RIFF")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(36+data.Len()))
+	buffer.WriteString("WAVEfmt ")
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint32{16})
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint16{1, uint16(numChannels)})
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint32{uint32(sampleRate), uint32(sampleRate * numChannels * 2)})
+	_ = binary.Write(&buffer, binary.LittleEndian, []uint16{uint16(numChannels * 2), 16})
+	buffer.WriteString("data")
+	_ = binary.Write(&buffer, binary.LittleEndian, size)
+	buffer.Write(data.Bytes())
+	return buffer.Bytes()
+}
+
+// sine returns numFrames frames of a sinusoid of the amplitude and frequency, the same in every channel.
+func sine(numChannels int, sampleRate int, numFrames int, amplitude float64, frequency float64) []int16 {
+	samples := make([]int16, numFrames*numChannels)
+	for i := 0; i < numFrames; i++ {
+		val := int16(math.Round(amplitude * 32767 * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate))))
+		for j := 0; j < numChannels; j++ {
+			samples[i*numChannels+j] = val
+		}
+	}
+	return samples
+}
+
+// readAllFrames reads the remaining frames of a stream, interleaved.
+func readAllFrames(stream StreamSource) ([]float64, error) {
+	numChannels := stream.Info().NumChannels
+	frames := make([]float64, 0)
+	buffer := make([]float64, 1000*numChannels)
+	for {
+		n, err := stream.ReadFrames(buffer)
+		frames = append(frames, buffer[:n*numChannels]...)
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+	}
+}
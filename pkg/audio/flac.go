@@ -1,58 +1,97 @@
 package audio
 
 import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
 	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+	"hash"
 	"io"
-	"os"
 )
 
+// flacChannelNames are the speaker names of the channels for each FLAC channel count, as defined by the FLAC format.
+var flacChannelNames = [][]string{
+	{"FC"},
+	{"FL", "FR"},
+	{"FL", "FR", "FC"},
+	{"FL", "FR", "BL", "BR"},
+	{"FL", "FR", "FC", "BL", "BR"},
+	{"FL", "FR", "FC", "LFE", "BL", "BR"},
+	{"FL", "FR", "FC", "LFE", "BC", "SL", "SR"},
+	{"FL", "FR", "FC", "LFE", "BL", "BR", "SL", "SR"},
+}
+
+func init() {
+	RegisterDecoder(&Decoder{
+		Name:       "flac",
+		MimeTypes:  []string{"audio/flac"},
+		Extensions: []string{".flac"},
+		Detect:     isFLAC,
+		Read:       sourceReader(ReadFLACFromReader),
+		Open:       streamOpener(NewFLACStreamFromReader),
+	})
+}
+
 type FLACSource struct {
 	info   Info
-	frames [][]float64
+	buffer *PlanarBuffer
 }
 
-func ReadFLACFromFile(path string) (*FLACSource, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+type FLACStream struct {
+	frameReader *flacFrameReader
+	info        Info
+	q           float64
+	frame       *frame.Frame
+	pos         int
+	readSeeker  io.ReadSeeker    // nil if the stream cannot seek
+	dataStart   int64            // offset of the first frame
+	seekPoints  []meta.SeekPoint // seek points, in increasing sample order
+}
 
-	return ReadFLACFromReader(f)
+// flacFrameReader parses FLAC frames, wrapping decode errors with the position of the frame, and checking the
+// decoded length and MD5 signature against STREAMINFO at the end of the stream.
+type flacFrameReader struct {
+	stream    *flac.Stream
+	options   ReadOptions
+	md5       hash.Hash
+	frameNum  int // -1 if unknown after seeking
+	sampleNum uint64
+	done      bool
 }
 
-func ReadFLACFromReader(reader io.Reader) (*FLACSource, error) {
+func ReadFLACFromReader(reader io.Reader, options ReadOptions) (*FLACSource, error) {
 	stream, err := flac.Parse(reader)
 	if err != nil {
 		return nil, err
 	}
 
 	flacFile := FLACSource{
-		info: Info{
-			NumChannels:   int(stream.Info.NChannels),
-			SampleRate:    int(stream.Info.SampleRate),
-			BitsPerSample: int(stream.Info.BitsPerSample),
-		},
-		frames: make([][]float64, 0),
+		info:   flacInfo(stream.Info),
+		buffer: NewPlanarBuffer(int(stream.Info.NChannels), options.SampleType),
 	}
+	flacFile.buffer.growForHeader(int64(stream.Info.NSamples))
 
-	s := 1 << (stream.Info.BitsPerSample - 1)
-	q := 1 / float64(s)
+	q := flacScale(stream.Info)
+	frameReader := newFLACFrameReader(stream, options)
 	for true {
-		src, err := stream.ParseNext()
+		src, err := frameReader.next()
 		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 		n := len(src.Subframes[0].Samples)
-		dst := make([][]float64, n)
-		for i := 0; i < n; i++ {
-			dst[i] = make([]float64, int(stream.Info.NChannels))
-			for j := 0; j < int(stream.Info.NChannels); j++ {
-				dst[i][j] = float64(src.Subframes[j].Samples[i]) * q
+		flacFile.buffer.Grow(n)
+		for j := 0; j < int(stream.Info.NChannels); j++ {
+			for i := 0; i < n; i++ {
+				flacFile.buffer.Append(j, float64(src.Subframes[j].Samples[i])*q)
 			}
 		}
-		flacFile.frames = append(flacFile.frames, dst...)
 	}
+	flacFile.info.NumFrames = int64(flacFile.buffer.NumFrames())
 
 	return &flacFile, nil
 }
@@ -62,5 +101,281 @@ func (f *FLACSource) Info() Info {
 }
 
 func (f *FLACSource) Frames() [][]float64 {
-	return f.frames
+	return f.buffer.Frames()
+}
+
+func (f *FLACSource) Buffer() *PlanarBuffer {
+	return f.buffer
+}
+
+// NewFLACStreamFromReader opens a stream over reader. If reader is seekable and the stream has a seek table, the
+// stream can seek.
+func NewFLACStreamFromReader(reader io.Reader, options ReadOptions) (*FLACStream, error) {
+	readSeeker, ok := reader.(io.ReadSeeker)
+	if ok && isSeekable(readSeeker) {
+		_, ok = readSeeker.(*bufferedReadSeeker)
+		if !ok {
+			// flac.NewSeek reads frames from readSeeker directly
+			readSeeker = newBufferedReadSeeker(readSeeker)
+		}
+		seekPoints, err := readFLACSeekPoints(readSeeker)
+		if err != nil {
+			return nil, err
+		}
+		if len(seekPoints) > 0 {
+			return newSeekableFLACStream(readSeeker, seekPoints, options)
+		}
+		reader = readSeeker
+	}
+
+	stream, err := flac.New(reader)
+	if err != nil {
+		return nil, err
+	}
+	return newFLACStream(stream, options), nil
+}
+
+func newFLACStream(stream *flac.Stream, options ReadOptions) *FLACStream {
+	return &FLACStream{
+		frameReader: newFLACFrameReader(stream, options),
+		info:        flacInfo(stream.Info),
+		q:           flacScale(stream.Info),
+	}
+}
+
+// newSeekableFLACStream opens a stream over readSeeker, which is positioned at the start of the FLAC stream. Seeking
+// uses the seek points directly, as flac.Stream.Seek miscounts the samples of a short last frame.
+func newSeekableFLACStream(readSeeker io.ReadSeeker, points []meta.SeekPoint,
+	options ReadOptions) (*FLACStream, error) {
+	stream, err := flac.NewSeek(readSeeker)
+	if err != nil {
+		return nil, err
+	}
+	dataStart, err := readSeeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	flacStream := newFLACStream(stream, options)
+	flacStream.readSeeker = readSeeker
+	flacStream.dataStart = dataStart
+	flacStream.seekPoints = points
+	return flacStream, nil
+}
+
+func (f *FLACStream) Info() Info {
+	return f.info
+}
+
+func (f *FLACStream) ReadFrames(buffer []float64) (int, error) {
+	numChannels := f.info.NumChannels
+	n := 0
+	for n < len(buffer)/numChannels {
+		if (f.frame == nil) || (f.pos >= len(f.frame.Subframes[0].Samples)) {
+			src, err := f.frameReader.next()
+			if err == io.EOF {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+			f.frame = src
+			f.pos = 0
+			continue
+		}
+		for j := 0; j < numChannels; j++ {
+			buffer[n*numChannels+j] = float64(f.frame.Subframes[j].Samples[f.pos]) * f.q
+		}
+		f.pos++
+		n++
+	}
+	return n, nil
+}
+
+// SeekFrame seeks to the last seek point at or before frame, then decodes the frames up to the one containing frame.
+// The MD5 signature is not verified after seeking.
+func (f *FLACStream) SeekFrame(frame int64) error {
+	if f.readSeeker == nil {
+		return ErrSeekUnsupported
+	}
+	if (frame < 0) || ((f.info.NumFrames > 0) && (frame > f.info.NumFrames)) {
+		return fmt.Errorf("cannot seek to frame %d of %d", frame, f.info.NumFrames)
+	}
+
+	point := meta.SeekPoint{}
+	for _, seekPoint := range f.seekPoints {
+		if seekPoint.SampleNum > uint64(frame) {
+			break
+		}
+		point = seekPoint
+	}
+	_, err := f.readSeeker.Seek(f.dataStart+int64(point.Offset), io.SeekStart)
+	if err != nil {
+		return err
+	}
+	f.frameReader.seek(point.SampleNum)
+	f.frame = nil
+	f.pos = 0
+
+	for {
+		src, err := f.frameReader.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if f.frameReader.sampleNum > uint64(frame) {
+			f.frame = src
+			f.pos = int(frame - int64(f.frameReader.sampleNum-uint64(src.BlockSize)))
+			return nil
+		}
+	}
+}
+
+func (f *FLACStream) Close() error {
+	return f.frameReader.stream.Close()
+}
+
+func newFLACFrameReader(stream *flac.Stream, options ReadOptions) *flacFrameReader {
+	frameReader := &flacFrameReader{
+		stream:  stream,
+		options: options,
+	}
+	if options.VerifyMD5 && (stream.Info.MD5sum != [md5.Size]uint8{}) {
+		frameReader.md5 = md5.New()
+	}
+	return frameReader
+}
+
+// next returns the next frame, or io.EOF at the end of the stream. In lenient mode, a decode error ends the stream.
+func (r *flacFrameReader) next() (*frame.Frame, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	src, err := r.stream.ParseNext()
+	if err == io.EOF {
+		r.done = true
+		err = r.verify()
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		r.done = true
+		if r.options.Lenient {
+			return nil, io.EOF
+		}
+		if r.frameNum < 0 {
+			return nil, fmt.Errorf("FLAC frame at sample %d: %w", r.sampleNum, err)
+		}
+		return nil, fmt.Errorf("FLAC frame %d at sample %d: %w", r.frameNum, r.sampleNum, err)
+	}
+	if r.md5 != nil {
+		src.Hash(r.md5)
+	}
+	if r.frameNum >= 0 {
+		r.frameNum++
+	}
+	r.sampleNum += uint64(src.BlockSize)
+	return src, nil
+}
+
+// seek resets the reader for the frame starting at sample sampleNum. The frame number is known only for streams with
+// a fixed block size.
+func (r *flacFrameReader) seek(sampleNum uint64) {
+	streamInfo := r.stream.Info
+	r.md5 = nil
+	r.sampleNum = sampleNum
+	r.frameNum = -1
+	if streamInfo.BlockSizeMin == streamInfo.BlockSizeMax {
+		r.frameNum = int(sampleNum / uint64(streamInfo.BlockSizeMax))
+	}
+	r.done = false
+}
+
+func (r *flacFrameReader) verify() error {
+	if r.options.Lenient {
+		return nil
+	}
+	if (r.stream.Info.NSamples != 0) && (r.sampleNum != r.stream.Info.NSamples) {
+		return fmt.Errorf("FLAC stream truncated: decoded %d of %d samples", r.sampleNum, r.stream.Info.NSamples)
+	}
+	if r.md5 != nil {
+		md5sum := r.md5.Sum(nil)
+		if !bytes.Equal(md5sum, r.stream.Info.MD5sum[:]) {
+			return fmt.Errorf("FLAC MD5 mismatch: expected %x, actual %x", r.stream.Info.MD5sum, md5sum)
+		}
+	}
+	return nil
+}
+
+func flacInfo(streamInfo *meta.StreamInfo) Info {
+	return Info{
+		NumChannels:   int(streamInfo.NChannels),
+		SampleRate:    int(streamInfo.SampleRate),
+		BitsPerSample: int(streamInfo.BitsPerSample),
+		ChannelNames:  flacChannelNames[streamInfo.NChannels-1],
+		NumFrames:     int64(streamInfo.NSamples),
+	}
+}
+
+// flacScale returns the factor that scales samples to [-1, 1).
+func flacScale(streamInfo *meta.StreamInfo) float64 {
+	return 1 / float64(int64(1)<<(streamInfo.BitsPerSample-1))
+}
+
+// readFLACSeekPoints reads the seek points of the FLAC stream at the current position of readSeeker, restoring the
+// position. Placeholder points are omitted. Returns no points if there is no seek table, or the metadata blocks are
+// invalid, which is left to the decoder to report.
+func readFLACSeekPoints(readSeeker io.ReadSeeker) ([]meta.SeekPoint, error) {
+	start, err := readSeeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	seekPoints := make([]meta.SeekPoint, 0)
+	signature := make([]byte, 4)
+	_, err = io.ReadFull(readSeeker, signature)
+	if (err == nil) && isFLAC(signature) {
+		for {
+			block, err := meta.New(readSeeker)
+			if err != nil {
+				break
+			}
+			if block.Type == meta.TypeSeekTable {
+				err = block.Parse()
+				if err != nil {
+					break
+				}
+				for _, seekPoint := range block.Body.(*meta.SeekTable).Points {
+					if seekPoint.SampleNum != meta.PlaceholderPoint {
+						seekPoints = append(seekPoints, seekPoint)
+					}
+				}
+				break
+			}
+			if block.IsLast {
+				break
+			}
+			err = block.Skip()
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	_, err = readSeeker.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return seekPoints, nil
+}
+
+func isFLAC(header []byte) bool {
+	return (len(header) >= 4) && (string(header[0:4]) == "fLaC")
 }
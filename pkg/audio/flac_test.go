@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The frames of testdata/seektable.flac start at byte 100, and all but the last one are 1034 bytes of 256 samples.
+const (
+	flacDataStart = 100
+	flacFrameSize = 1034
+)
+
+func TestReadFLACErrors(t *testing.T) {
+	file, err := os.ReadFile("testdata/seektable.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	badMD5 := append([]byte{}, file...)
+	// the last byte of the MD5 signature in STREAMINFO
+	badMD5[4+4+33] ^= 0xff
+	badCRC := append([]byte{}, file...)
+	badCRC[flacDataStart+2*flacFrameSize+100] ^= 0xff
+
+	tests := []struct {
+		name          string
+		file          []byte
+		options       ReadOptions
+		expectedError string
+		numFrames     int64 // the frames decoded in lenient mode
+	}{
+		{"intact", file, ReadOptions{VerifyMD5: true}, "", 3000},
+		{"bad MD5 signature", badMD5, ReadOptions{VerifyMD5: true}, "MD5 mismatch", 3000},
+		{"bad MD5 signature, unverified", badMD5, ReadOptions{}, "", 3000},
+		{"bad frame CRC", badCRC, ReadOptions{}, "FLAC frame 2 at sample 512", 512},
+		{"truncated between frames", file[:flacDataStart+4*flacFrameSize], ReadOptions{},
+			"decoded 1024 of 3000 samples", 1024},
+		{"truncated within a frame", file[:flacDataStart+4*flacFrameSize+500], ReadOptions{},
+			"FLAC frame 4 at sample 1024", 1024},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, err := ReadFLACFromReader(bytes.NewReader(test.file), test.options)
+			if test.expectedError == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if src.Info().NumFrames != test.numFrames {
+					t.Errorf("expected %d frames, found %d", test.numFrames, src.Info().NumFrames)
+				}
+				return
+			}
+			if (err == nil) || !strings.Contains(err.Error(), test.expectedError) {
+				t.Fatalf("expected an error containing %q, found %v", test.expectedError, err)
+			}
+
+			options := test.options
+			options.Lenient = true
+			src, err = ReadFLACFromReader(bytes.NewReader(test.file), options)
+			if err != nil {
+				t.Fatalf("expected no error in lenient mode, found %v", err)
+			}
+			if src.Info().NumFrames != test.numFrames {
+				t.Errorf("expected %d frames in lenient mode, found %d", test.numFrames, src.Info().NumFrames)
+			}
+		})
+	}
+}
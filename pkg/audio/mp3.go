@@ -0,0 +1,354 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/go-mp3"
+	"io"
+)
+
+// mp3DecoderDelay is the delay, in samples, introduced by the MP3 synthesis filterbank.
+const mp3DecoderDelay = 529
+
+var (
+	mp3BitRates = [2][15]int{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}, // MPEG-1
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},     // MPEG-2, MPEG-2.5
+	}
+	mp3SampleRates = map[int][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+	// mp3ChannelNames are the names of the channels for each MP3 channel count: mono, or stereo, joint stereo and
+	// dual channel, which are decoded as left and right
+	mp3ChannelNames = [][]string{
+		{"M"},
+		{"L", "R"},
+	}
+)
+
+func init() {
+	RegisterDecoder(&Decoder{
+		Name:       "mp3",
+		MimeTypes:  []string{"audio/mpeg"},
+		Extensions: []string{".mp3"},
+		Detect:     isMP3,
+		Read:       sourceReader(ReadMP3FromReader),
+		Open:       streamOpener(NewMP3StreamFromReader),
+	})
+}
+
+type MP3Source struct {
+	info   Info
+	buffer *PlanarBuffer
+}
+
+// MP3Stream decodes MPEG audio layer III. Gapless playback information from a Xing/LAME header is used to remove
+// the encoder and decoder delay and the end padding.
+type MP3Stream struct {
+	decoder   *mp3.Decoder
+	options   ReadOptions
+	info      Info
+	buffer    []byte
+	skip      int64 // frames still to be discarded from the start
+	remaining int64 // frames left to return, or -1 if unknown
+}
+
+// mp3FrameHeader is the header of an MPEG audio layer III frame.
+type mp3FrameHeader struct {
+	version         int // 3 = MPEG-1, 2 = MPEG-2, 0 = MPEG-2.5
+	numChannels     int
+	sampleRate      int
+	frameSize       int
+	samplesPerFrame int
+	sideInfoSize    int
+}
+
+// mp3GaplessInfo is the information in the Xing/Info header and LAME tag.
+type mp3GaplessInfo struct {
+	numFrames      int64 // audio frames, excluding the Xing/Info frame itself
+	encoderDelay   int
+	encoderPadding int
+	hasLAMETag     bool
+}
+
+func ReadMP3FromReader(reader io.Reader, options ReadOptions) (*MP3Source, error) {
+	stream, err := NewMP3StreamFromReader(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readAll(stream, options.SampleType)
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	info.NumFrames = int64(buffer.NumFrames())
+	return &MP3Source{
+		info:   info,
+		buffer: buffer,
+	}, nil
+}
+
+func (m *MP3Source) Info() Info {
+	return m.info
+}
+
+func (m *MP3Source) Frames() [][]float64 {
+	return m.buffer.Frames()
+}
+
+func (m *MP3Source) Buffer() *PlanarBuffer {
+	return m.buffer
+}
+
+func NewMP3StreamFromReader(reader io.Reader, options ReadOptions) (*MP3Stream, error) {
+	head, err := skipID3v2(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse the first frame to find the Xing/LAME header, then hand it back to the decoder
+	header, err := parseMP3FrameHeader(head)
+	if err != nil {
+		return nil, err
+	}
+	firstFrame := make([]byte, header.frameSize)
+	copy(firstFrame, head)
+	_, err = io.ReadFull(reader, firstFrame[4:])
+	if err != nil {
+		return nil, err
+	}
+	gaplessInfo := parseMP3GaplessInfo(firstFrame, header)
+
+	decoder, err := mp3.NewDecoder(io.MultiReader(bytes.NewReader(firstFrame), reader))
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &MP3Stream{
+		decoder: decoder,
+		options: options,
+		info: Info{
+			NumChannels:   header.numChannels,
+			SampleRate:    decoder.SampleRate(),
+			BitsPerSample: 16,
+			ChannelNames:  mp3ChannelNames[header.numChannels-1],
+		},
+		remaining: -1,
+	}
+	if gaplessInfo != nil {
+		// the Xing/Info frame decodes to a frame of silence
+		stream.skip = int64(header.samplesPerFrame)
+		stream.remaining = gaplessInfo.numFrames * int64(header.samplesPerFrame)
+		if gaplessInfo.hasLAMETag {
+			stream.skip += int64(gaplessInfo.encoderDelay + mp3DecoderDelay)
+			stream.remaining -= int64(gaplessInfo.encoderDelay + gaplessInfo.encoderPadding)
+		}
+		if stream.remaining < 0 {
+			stream.remaining = 0
+		}
+		stream.info.NumFrames = stream.remaining
+	}
+
+	return stream, nil
+}
+
+func (m *MP3Stream) Info() Info {
+	return m.info
+}
+
+func (m *MP3Stream) ReadFrames(buffer []float64) (int, error) {
+	// the decoder always produces 16-bit little-endian stereo
+	const bytesPerFrame = 4
+
+	numChannels := m.info.NumChannels
+	numFrames := len(buffer) / numChannels
+	if (m.remaining >= 0) && (int64(numFrames) > m.remaining) {
+		numFrames = int(m.remaining)
+	}
+	if numFrames == 0 {
+		return 0, io.EOF
+	}
+
+	for m.skip > 0 {
+		skipFrames := m.skip
+		if skipFrames > int64(numFrames) {
+			skipFrames = int64(numFrames)
+		}
+		n, err := m.readDecoded(int(skipFrames) * bytesPerFrame)
+		if err != nil {
+			return 0, m.decodeError(err)
+		}
+		m.skip -= int64(n / bytesPerFrame)
+	}
+
+	n, err := m.readDecoded(numFrames * bytesPerFrame)
+	if (err != nil) && ((n == 0) || !m.options.Lenient) {
+		return 0, m.decodeError(err)
+	}
+	n /= bytesPerFrame
+	if err != nil {
+		// lenient: the stream ends with the frames decoded before the error
+		m.remaining = int64(n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < numChannels; j++ {
+			sample := int16(binary.LittleEndian.Uint16(m.buffer[i*bytesPerFrame+j*2:]))
+			buffer[i*numChannels+j] = float64(sample) / 32768
+		}
+	}
+	if m.remaining >= 0 {
+		m.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+// decodeError returns err, or ends the stream with io.EOF if the options are lenient.
+func (m *MP3Stream) decodeError(err error) error {
+	if m.options.Lenient && (err != io.EOF) {
+		m.remaining = 0
+		return io.EOF
+	}
+	return err
+}
+
+// readDecoded reads up to size bytes of decoded audio into m.buffer, returning io.EOF at the end of the stream.
+func (m *MP3Stream) readDecoded(size int) (int, error) {
+	if len(m.buffer) < size {
+		m.buffer = make([]byte, size)
+	}
+	n, err := io.ReadFull(m.decoder, m.buffer[:size])
+	if (err == io.ErrUnexpectedEOF) || ((err == io.EOF) && (n > 0)) {
+		err = nil
+	}
+	return n - (n % 4), err
+}
+
+func (m *MP3Stream) Close() error {
+	return nil
+}
+
+func isMP3(header []byte) bool {
+	if (len(header) >= 3) && (string(header[0:3]) == "ID3") {
+		// also used by other formats; leave it to the MIME type
+		return false
+	}
+	_, err := parseMP3FrameHeader(header)
+	return err == nil
+}
+
+// skipID3v2 skips ID3v2 tags at the start of the stream, returning the first 4 bytes after them.
+func skipID3v2(reader io.Reader) ([]byte, error) {
+	header := make([]byte, 10)
+	_, err := io.ReadFull(reader, header[0:4])
+	if err != nil {
+		return nil, err
+	}
+	for string(header[0:3]) == "ID3" {
+		_, err = io.ReadFull(reader, header[4:10])
+		if err != nil {
+			return nil, err
+		}
+		size := int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 | int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f)
+		if (header[5] & 0x10) != 0 {
+			// footer present
+			size += 10
+		}
+		_, err = io.CopyN(io.Discard, reader, size)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.ReadFull(reader, header[0:4])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return header[0:4], nil
+}
+
+func parseMP3FrameHeader(b []byte) (*mp3FrameHeader, error) {
+	if (len(b) < 4) || (b[0] != 0xff) || ((b[1] & 0xe0) != 0xe0) {
+		return nil, errors.New("MP3 frame sync not found")
+	}
+	version := int((b[1] >> 3) & 0x03)
+	layer := (b[1] >> 1) & 0x03
+	bitRateIndex := int(b[2] >> 4)
+	sampleRateIndex := int((b[2] >> 2) & 0x03)
+	padding := int((b[2] >> 1) & 0x01)
+	channelMode := b[3] >> 6
+	if (version == 1) || (layer != 1) || (bitRateIndex == 0) || (bitRateIndex == 15) || (sampleRateIndex == 3) {
+		return nil, fmt.Errorf("unsupported MPEG audio frame header: %x", b[0:4])
+	}
+
+	header := &mp3FrameHeader{
+		version:     version,
+		numChannels: 2,
+		sampleRate:  mp3SampleRates[version][sampleRateIndex],
+	}
+	if channelMode == 3 {
+		header.numChannels = 1
+	}
+	if version == 3 {
+		header.samplesPerFrame = 1152
+		header.frameSize = 144*mp3BitRates[0][bitRateIndex]*1000/header.sampleRate + padding
+		header.sideInfoSize = 32
+		if header.numChannels == 1 {
+			header.sideInfoSize = 17
+		}
+	} else {
+		header.samplesPerFrame = 576
+		header.frameSize = 72*mp3BitRates[1][bitRateIndex]*1000/header.sampleRate + padding
+		header.sideInfoSize = 17
+		if header.numChannels == 1 {
+			header.sideInfoSize = 9
+		}
+	}
+	return header, nil
+}
+
+// parseMP3GaplessInfo parses the Xing/Info header and LAME tag in the first frame. Returns nil if not present.
+func parseMP3GaplessInfo(frame []byte, header *mp3FrameHeader) *mp3GaplessInfo {
+	offset := 4 + header.sideInfoSize
+	if len(frame) < offset+8 {
+		return nil
+	}
+	tag := string(frame[offset : offset+4])
+	if (tag != "Xing") && (tag != "Info") {
+		return nil
+	}
+	flags := binary.BigEndian.Uint32(frame[offset+4:])
+	offset += 8
+	if (flags & 0x1) == 0 {
+		return nil
+	}
+	if len(frame) < offset+4 {
+		return nil
+	}
+	gaplessInfo := &mp3GaplessInfo{
+		numFrames: int64(binary.BigEndian.Uint32(frame[offset:])),
+	}
+	offset += 4
+	if (flags & 0x2) != 0 {
+		offset += 4 // bytes
+	}
+	if (flags & 0x4) != 0 {
+		offset += 100 // TOC
+	}
+	if (flags & 0x8) != 0 {
+		offset += 4 // quality
+	}
+	if len(frame) >= offset+24 {
+		encoder := string(frame[offset : offset+4])
+		if (encoder == "LAME") || (encoder == "Lavf") || (encoder == "Lavc") {
+			gaplessInfo.hasLAMETag = true
+			gaplessInfo.encoderDelay = int(frame[offset+21])<<4 | int(frame[offset+22])>>4
+			gaplessInfo.encoderPadding = int(frame[offset+22]&0x0f)<<8 | int(frame[offset+23])
+		}
+	}
+	return gaplessInfo
+}
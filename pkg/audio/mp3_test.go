@@ -0,0 +1,131 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// infoFrame returns a silent MPEG-1 frame matching testdata/frames.mp3 (32 kHz, 224 kbit/s, stereo), holding an
+// Info header with the number of frames and, if lame is set, a LAME tag with the encoder delay and padding.
+func infoFrame(numFrames int, lame bool, encoderDelay int, encoderPadding int) []byte {
+	frame := make([]byte, 1008)
+	copy(frame, []byte{0xff, 0xfb, 0xc8, 0x04})
+	offset := 4 + 32
+	copy(frame[offset:], "Info")
+	binary.BigEndian.PutUint32(frame[offset+4:], 0x1)
+	binary.BigEndian.PutUint32(frame[offset+8:], uint32(numFrames))
+	offset += 12
+	if lame {
+		copy(frame[offset:], "LAME3.100")
+		frame[offset+21] = byte(encoderDelay >> 4)
+		frame[offset+22] = byte(encoderDelay<<4 | encoderPadding>>8)
+		frame[offset+23] = byte(encoderPadding)
+	}
+	return frame
+}
+
+// readMP3 decodes an MP3 stream, returning the length from the Info header and the frames interleaved.
+func readMP3(t *testing.T, file []byte) (int64, []float64) {
+	stream, err := NewMP3StreamFromReader(bytes.NewReader(file), ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := readAllFrames(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream.Info().NumFrames, frames
+}
+
+func TestMP3Gapless(t *testing.T) {
+	// 10 frames of 1152 samples, without a Xing/Info header
+	data, err := os.ReadFile("testdata/frames.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numFrames, decoded := readMP3(t, data)
+	if numFrames != 0 {
+		t.Errorf("expected an unknown length without the Info header, found %d frames", numFrames)
+	}
+	if len(decoded) != 2*10*1152 {
+		t.Fatalf("expected %d frames, found %d", 10*1152, len(decoded)/2)
+	}
+
+	// an ID3v2 tag with a footer
+	id3 := append([]byte("ID3"), 4, 0, 0x10, 0, 0, 0, 6)
+	id3 = append(id3, "TIT2\x00\x00"...)
+	id3 = append(id3, "3DI"...)
+	id3 = append(id3, 4, 0, 0x10, 0, 0, 0, 6)
+
+	tests := []struct {
+		name   string
+		file   []byte
+		skip   int
+		length int
+	}{
+		// the Info frame decodes to silence and is always removed
+		{"Info", append(infoFrame(10, false, 0, 0), data...), 0, 10 * 1152},
+		// the encoder delay and the decoder delay are removed from the start, the padding from the end
+		{"LAME", append(infoFrame(10, true, 576, 1200), data...), 576 + 529, 10*1152 - 576 - 1200},
+		{"ID3v2 and LAME", append(append(id3, infoFrame(10, true, 576, 1200)...), data...), 576 + 529,
+			10*1152 - 576 - 1200},
+		// fewer frames than in the stream
+		{"LAME, truncated", append(infoFrame(4, true, 1000, 100), data...), 1000 + 529, 4*1152 - 1000 - 100},
+	}
+	for _, test := range tests {
+		numFrames, frames := readMP3(t, test.file)
+		if numFrames != int64(test.length) {
+			t.Errorf("%s: expected a length of %d frames, found %d", test.name, test.length, numFrames)
+		}
+		expected := decoded[2*test.skip : 2*(test.skip+test.length)]
+		if len(frames) != len(expected) {
+			t.Errorf("%s: expected %d frames, found %d", test.name, len(expected)/2, len(frames)/2)
+			continue
+		}
+		for i := range expected {
+			if frames[i] != expected[i] {
+				t.Errorf("%s: sample %d: expected %g, found %g", test.name, i, expected[i], frames[i])
+				break
+			}
+		}
+	}
+}
+
+func TestMP3Lenient(t *testing.T) {
+	data, err := os.ReadFile("testdata/frames.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expected := readMP3(t, data)
+	// the sixth frame is layer I
+	file := append([]byte{}, data...)
+	file[5*1008+1] = 0xff
+
+	stream, err := NewMP3StreamFromReader(bytes.NewReader(file), ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(stream.Info().ChannelNames) != 2) || (stream.Info().ChannelNames[0] != "L") {
+		t.Errorf("expected the channels L and R, found %q", stream.Info().ChannelNames)
+	}
+	_, err = readAllFrames(stream)
+	if err == nil {
+		t.Errorf("expected an error for the layer I frame")
+	}
+
+	src, err := ReadMP3FromReader(bytes.NewReader(file), ReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := src.Frames()
+	if (len(frames) == 0) || (len(frames) >= 10*1152) {
+		t.Fatalf("expected the frames before the error, found %d", len(frames))
+	}
+	for i, frame := range frames {
+		if (frame[0] != expected[2*i]) || (frame[1] != expected[2*i+1]) {
+			t.Fatalf("frame %d: expected %g, found %g", i, expected[2*i:2*i+2], frame)
+		}
+	}
+}
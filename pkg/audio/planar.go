@@ -0,0 +1,170 @@
+package audio
+
+type SampleType int
+
+const (
+	SampleTypeFloat64 SampleType = iota
+	SampleTypeFloat32
+)
+
+// PlanarBuffer holds the samples of each channel contiguously, as either float64 or float32.
+type PlanarBuffer struct {
+	sampleType      SampleType
+	float64Channels [][]float64
+	float32Channels [][]float32
+}
+
+// PlanarSource is a Source whose samples are held in a PlanarBuffer. Its Frames method returns a frame-major copy of
+// the buffer; use Buffer to avoid the copy.
+type PlanarSource interface {
+	Source
+	Buffer() *PlanarBuffer
+}
+
+func NewPlanarBuffer(numChannels int, sampleType SampleType) *PlanarBuffer {
+	buffer := &PlanarBuffer{
+		sampleType: sampleType,
+	}
+	if sampleType == SampleTypeFloat32 {
+		buffer.float32Channels = make([][]float32, numChannels)
+	} else {
+		buffer.float64Channels = make([][]float64, numChannels)
+	}
+	return buffer
+}
+
+func (b *PlanarBuffer) SampleType() SampleType {
+	return b.sampleType
+}
+
+func (b *PlanarBuffer) NumChannels() int {
+	if b.sampleType == SampleTypeFloat32 {
+		return len(b.float32Channels)
+	}
+	return len(b.float64Channels)
+}
+
+func (b *PlanarBuffer) NumFrames() int {
+	if b.NumChannels() == 0 {
+		return 0
+	}
+	if b.sampleType == SampleTypeFloat32 {
+		return len(b.float32Channels[0])
+	}
+	return len(b.float64Channels[0])
+}
+
+// Float64Channel returns the samples of a channel, or nil if the buffer holds float32 samples.
+func (b *PlanarBuffer) Float64Channel(channel int) []float64 {
+	if b.sampleType == SampleTypeFloat32 {
+		return nil
+	}
+	return b.float64Channels[channel]
+}
+
+// Float32Channel returns the samples of a channel, or nil if the buffer holds float64 samples.
+func (b *PlanarBuffer) Float32Channel(channel int) []float32 {
+	if b.sampleType != SampleTypeFloat32 {
+		return nil
+	}
+	return b.float32Channels[channel]
+}
+
+func (b *PlanarBuffer) Sample(channel int, frame int) float64 {
+	if b.sampleType == SampleTypeFloat32 {
+		return float64(b.float32Channels[channel][frame])
+	}
+	return b.float64Channels[channel][frame]
+}
+
+// CopyChannel copies the samples of a channel, starting at frame offset, into dst. Returns the number of samples
+// copied.
+func (b *PlanarBuffer) CopyChannel(channel int, offset int, dst []float64) int {
+	if b.sampleType == SampleTypeFloat32 {
+		src := b.float32Channels[channel][offset:]
+		n := len(dst)
+		if n > len(src) {
+			n = len(src)
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = float64(src[i])
+		}
+		return n
+	}
+	return copy(dst, b.float64Channels[channel][offset:])
+}
+
+// Grow ensures there is capacity for another n frames. The capacity at least doubles, so that growing by a frame of
+// samples at a time takes amortized linear time.
+func (b *PlanarBuffer) Grow(n int) {
+	for i := range b.float64Channels {
+		channel := b.float64Channels[i]
+		if cap(channel)-len(channel) < n {
+			grown := make([]float64, len(channel), grownCapacity(len(channel), cap(channel), n))
+			copy(grown, channel)
+			b.float64Channels[i] = grown
+		}
+	}
+	for i := range b.float32Channels {
+		channel := b.float32Channels[i]
+		if cap(channel)-len(channel) < n {
+			grown := make([]float32, len(channel), grownCapacity(len(channel), cap(channel), n))
+			copy(grown, channel)
+			b.float32Channels[i] = grown
+		}
+	}
+}
+
+func grownCapacity(length int, capacity int, n int) int {
+	if length+n > 2*capacity {
+		return length + n
+	}
+	return 2 * capacity
+}
+
+// growForHeader reserves capacity for the number of frames according to an (untrusted) header, up to
+// maxPreallocatedSamples over all channels. Appending grows the buffer past that as needed.
+func (b *PlanarBuffer) growForHeader(numFrames int64) {
+	numChannels := b.NumChannels()
+	if (numFrames <= 0) || (numChannels < 1) {
+		return
+	}
+	maxFrames := int64(maxPreallocatedSamples / numChannels)
+	if numFrames > maxFrames {
+		numFrames = maxFrames
+	}
+	b.Grow(int(numFrames))
+}
+
+// Append appends a sample to a channel. Callers must append the same number of samples to every channel.
+func (b *PlanarBuffer) Append(channel int, value float64) {
+	if b.sampleType == SampleTypeFloat32 {
+		b.float32Channels[channel] = append(b.float32Channels[channel], float32(value))
+	} else {
+		b.float64Channels[channel] = append(b.float64Channels[channel], value)
+	}
+}
+
+// AppendInterleaved appends numFrames interleaved frames.
+func (b *PlanarBuffer) AppendInterleaved(interleaved []float64, numFrames int) {
+	numChannels := b.NumChannels()
+	for j := 0; j < numChannels; j++ {
+		for i := 0; i < numFrames; i++ {
+			b.Append(j, interleaved[i*numChannels+j])
+		}
+	}
+}
+
+// Frames returns a frame-major copy of the samples.
+func (b *PlanarBuffer) Frames() [][]float64 {
+	numChannels := b.NumChannels()
+	frames := make([][]float64, b.NumFrames())
+	samples := make([]float64, len(frames)*numChannels)
+	for i := range frames {
+		frames[i] = samples[i*numChannels : (i+1)*numChannels]
+		for j := 0; j < numChannels; j++ {
+			frames[i][j] = b.Sample(j, i)
+		}
+	}
+	return frames
+}
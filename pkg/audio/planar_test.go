@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadWAVLimitsPreallocation(t *testing.T) {
+	// 2 frames, with a data sub-chunk size claiming 2^32 - 16 bytes
+	file := wavFile(8, 48000, make([]int16, 16), 0xfffffff0)
+
+	src, err := ReadWAVFromReader(bytes.NewReader(file), ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := src.Buffer()
+	if buffer.NumFrames() != 2 {
+		t.Fatalf("expected 2 frames, found %d", buffer.NumFrames())
+	}
+	for j := 0; j < 8; j++ {
+		capacity := cap(buffer.Float64Channel(j))
+		if capacity > maxPreallocatedSamples/8 {
+			t.Errorf("channel %d: expected a capacity of at most %d, found %d", j, maxPreallocatedSamples/8, capacity)
+		}
+	}
+}
+
+func TestPlanarBufferGrow(t *testing.T) {
+	buffer := NewPlanarBuffer(2, SampleTypeFloat32)
+	reallocations := 0
+	previousCapacity := -1
+	for i := 0; i < 10000; i++ {
+		buffer.Grow(100)
+		for n := 0; n < 100; n++ {
+			buffer.Append(0, float64(i))
+			buffer.Append(1, -float64(i))
+		}
+		if capacity := cap(buffer.Float32Channel(0)); capacity != previousCapacity {
+			reallocations++
+			previousCapacity = capacity
+		}
+	}
+	if buffer.NumFrames() != 1000000 {
+		t.Fatalf("expected 1000000 frames, found %d", buffer.NumFrames())
+	}
+	if (buffer.Sample(0, 999999) != 9999) || (buffer.Sample(1, 500) != -5) {
+		t.Errorf("unexpected samples %g, %g", buffer.Sample(0, 999999), buffer.Sample(1, 500))
+	}
+	if reallocations > 20 {
+		t.Errorf("expected the capacity to grow geometrically, reallocated %d times", reallocations)
+	}
+}
@@ -0,0 +1,270 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type RawEncoding int
+
+const (
+	RawEncodingS8 RawEncoding = iota
+	RawEncodingU8
+	RawEncodingS16
+	RawEncodingS24
+	RawEncodingS32
+	RawEncodingF32
+	RawEncodingF64
+)
+
+var rawEncodingNames = map[string]RawEncoding{
+	"s8":  RawEncodingS8,
+	"u8":  RawEncodingU8,
+	"s16": RawEncodingS16,
+	"s24": RawEncodingS24,
+	"s32": RawEncodingS32,
+	"f32": RawEncodingF32,
+	"f64": RawEncodingF64,
+}
+
+// RawFormat declares the format of headerless, interleaved PCM.
+type RawFormat struct {
+	SampleRate  int
+	NumChannels int
+	Encoding    RawEncoding
+	ByteOrder   binary.ByteOrder
+}
+
+type RawSource struct {
+	info   Info
+	buffer *PlanarBuffer
+}
+
+type RawStream struct {
+	reader io.Reader
+	format RawFormat
+	info   Info
+	buffer []byte
+}
+
+// ParseRawFormat parses a format of the form encoding[le|be]:sample_rate:channels, e.g. "s16le:48000:2" or
+// "f32be:8000:1". The encoding is one of s8, u8, s16, s24, s32, f32 and f64; the byte order defaults to little-endian.
+func ParseRawFormat(s string) (*RawFormat, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid raw format: %s", s)
+	}
+
+	format := RawFormat{
+		ByteOrder: binary.LittleEndian,
+	}
+	encodingName := strings.ToLower(parts[0])
+	if strings.HasSuffix(encodingName, "le") {
+		encodingName = strings.TrimSuffix(encodingName, "le")
+	} else if strings.HasSuffix(encodingName, "be") {
+		encodingName = strings.TrimSuffix(encodingName, "be")
+		format.ByteOrder = binary.BigEndian
+	}
+	encoding, ok := rawEncodingNames[encodingName]
+	if !ok {
+		return nil, fmt.Errorf("unknown raw sample encoding: %s", parts[0])
+	}
+	format.Encoding = encoding
+
+	sampleRate, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw sample rate: %s", parts[1])
+	}
+	format.SampleRate = sampleRate
+	numChannels, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw channel count: %s", parts[2])
+	}
+	format.NumChannels = numChannels
+
+	err = format.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
+
+// BytesPerSample returns the size of a sample of one channel.
+func (format RawFormat) BytesPerSample() int {
+	switch format.Encoding {
+	case RawEncodingS16:
+		return 2
+	case RawEncodingS24:
+		return 3
+	case RawEncodingS32, RawEncodingF32:
+		return 4
+	case RawEncodingF64:
+		return 8
+	default:
+		return 1
+	}
+}
+
+func (format RawFormat) validate() error {
+	if format.SampleRate <= 0 {
+		return fmt.Errorf("invalid raw sample rate: %d", format.SampleRate)
+	}
+	if format.NumChannels <= 0 {
+		return fmt.Errorf("invalid raw channel count: %d", format.NumChannels)
+	}
+	if (format.Encoding < RawEncodingS8) || (format.Encoding > RawEncodingF64) {
+		return fmt.Errorf("unknown raw sample encoding: %d", format.Encoding)
+	}
+	return nil
+}
+
+func (format RawFormat) byteOrder() binary.ByteOrder {
+	if format.ByteOrder == nil {
+		return binary.LittleEndian
+	}
+	return format.ByteOrder
+}
+
+func (format RawFormat) decodeSample(b []byte) float64 {
+	byteOrder := format.byteOrder()
+	switch format.Encoding {
+	case RawEncodingS8:
+		return float64(int8(b[0])) / 128
+	case RawEncodingU8:
+		return (float64(b[0]) - 128) / 128
+	case RawEncodingS16:
+		return float64(int16(byteOrder.Uint16(b))) / 32768
+	case RawEncodingS24:
+		var v int32
+		if byteOrder == binary.BigEndian {
+			v = int32(b[0])<<24 | int32(b[1])<<16 | int32(b[2])<<8
+		} else {
+			v = int32(b[2])<<24 | int32(b[1])<<16 | int32(b[0])<<8
+		}
+		return float64(v>>8) / (1 << 23)
+	case RawEncodingS32:
+		return float64(int32(byteOrder.Uint32(b))) / (1 << 31)
+	case RawEncodingF32:
+		return float64(math.Float32frombits(byteOrder.Uint32(b)))
+	default:
+		return math.Float64frombits(byteOrder.Uint64(b))
+	}
+}
+
+// ReadFromReader reads raw PCM in the format from reader.
+func (format RawFormat) ReadFromReader(reader io.Reader, options ReadOptions) (*RawSource, error) {
+	stream, err := format.NewStreamFromReader(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readAll(stream, options.SampleType)
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	info.NumFrames = int64(buffer.NumFrames())
+	return &RawSource{
+		info:   info,
+		buffer: buffer,
+	}, nil
+}
+
+func (r *RawSource) Info() Info {
+	return r.info
+}
+
+func (r *RawSource) Frames() [][]float64 {
+	return r.buffer.Frames()
+}
+
+func (r *RawSource) Buffer() *PlanarBuffer {
+	return r.buffer
+}
+
+// NewStreamFromReader opens a stream of raw PCM in the format over reader. If reader is seekable, such as a file, the
+// number of frames is derived from the size of the rest of it.
+func (format RawFormat) NewStreamFromReader(reader io.Reader, options ReadOptions) (*RawStream, error) {
+	err := format.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	bitsPerSample := format.BytesPerSample() * 8
+	stream := &RawStream{
+		reader: reader,
+		format: format,
+		info: Info{
+			NumChannels:   format.NumChannels,
+			SampleRate:    format.SampleRate,
+			BitsPerSample: bitsPerSample,
+			ChannelNames:  make([]string, format.NumChannels),
+		},
+	}
+	readSeeker, ok := reader.(io.ReadSeeker)
+	if ok && isSeekable(readSeeker) {
+		size, err := remainingSize(readSeeker)
+		if err != nil {
+			return nil, err
+		}
+		stream.info.NumFrames = size / int64(format.BytesPerSample()*format.NumChannels)
+	}
+	return stream, nil
+}
+
+func (r *RawStream) Info() Info {
+	return r.info
+}
+
+// ReadFrames decodes up to len(buffer) / NumChannels frames into buffer, interleaved. A truncated trailing frame is
+// dropped.
+func (r *RawStream) ReadFrames(buffer []float64) (int, error) {
+	numChannels := r.format.NumChannels
+	bytesPerSample := r.format.BytesPerSample()
+	blockAlign := numChannels * bytesPerSample
+	size := len(buffer) / numChannels * blockAlign
+	if len(r.buffer) < size {
+		r.buffer = make([]byte, size)
+	}
+
+	n, err := io.ReadFull(r.reader, r.buffer[:size])
+	numFrames := n / blockAlign
+	if (err == io.EOF) || (err == io.ErrUnexpectedEOF) {
+		if numFrames == 0 {
+			return 0, io.EOF
+		}
+		err = nil
+	}
+
+	for i := 0; i < numFrames*numChannels; i++ {
+		buffer[i] = r.format.decodeSample(r.buffer[i*bytesPerSample : (i+1)*bytesPerSample])
+	}
+	return numFrames, err
+}
+
+func (r *RawStream) Close() error {
+	return nil
+}
+
+// remainingSize returns the number of bytes from the current position of seeker to the end, leaving the position
+// unchanged.
+func remainingSize(seeker io.Seeker) (int64, error) {
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = seeker.Seek(pos, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	return end - pos, nil
+}
@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRawFormat(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected RawFormat
+	}{
+		{"s16le:48000:2", RawFormat{48000, 2, RawEncodingS16, binary.LittleEndian}},
+		{"f32be:8000:1", RawFormat{8000, 1, RawEncodingF32, binary.BigEndian}},
+		{"S24BE:96000:6", RawFormat{96000, 6, RawEncodingS24, binary.BigEndian}},
+		// the byte order defaults to little-endian
+		{"u8:11025:1", RawFormat{11025, 1, RawEncodingU8, binary.LittleEndian}},
+		{"f64:44100:2", RawFormat{44100, 2, RawEncodingF64, binary.LittleEndian}},
+	}
+	for _, test := range tests {
+		found, err := ParseRawFormat(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+		if *found != test.expected {
+			t.Errorf("%q: expected %+v, found %+v", test.s, test.expected, *found)
+		}
+	}
+
+	for _, s := range []string{"", "s16le", "s16le:48000", "s16le:48000:2:1", "s12le:48000:2", "le:48000:2",
+		"s16le:0:2", "s16le:-8000:2", "s16le:48000:0", "s16le:48k:2", "s16le:48000:two"} {
+		found, err := ParseRawFormat(s)
+		if err == nil {
+			t.Errorf("%q: expected an error, found %+v", s, *found)
+		}
+	}
+}
+
+func TestReadRaw(t *testing.T) {
+	float32LE := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.25))
+	float32LE = binary.LittleEndian.AppendUint32(float32LE, math.Float32bits(-1.5))
+	float64BE := binary.BigEndian.AppendUint64(nil, math.Float64bits(0.1))
+	float64BE = binary.BigEndian.AppendUint64(float64BE, math.Float64bits(-3))
+	tests := []struct {
+		format   string
+		data     []byte
+		expected []float64
+	}{
+		{"s8:8000:1", []byte{0x40, 0x80}, []float64{0.5, -1}},
+		{"u8:8000:1", []byte{0xc0, 0x00}, []float64{0.5, -1}},
+		{"s16le:8000:1", []byte{0x00, 0x40, 0xff, 0xff}, []float64{0.5, -1.0 / 32768}},
+		{"s16be:8000:1", []byte{0x40, 0x00, 0xff, 0xff}, []float64{0.5, -1.0 / 32768}},
+		{"s24le:8000:1", []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0x80}, []float64{0.5, -1}},
+		{"s24be:8000:1", []byte{0x40, 0x00, 0x00, 0xff, 0xff, 0xff}, []float64{0.5, -1.0 / 8388608}},
+		{"s32le:8000:1", []byte{0, 0, 0, 0x40, 0, 0, 0, 0x80}, []float64{0.5, -1}},
+		{"s32be:8000:1", []byte{0x40, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}, []float64{0.5, -1.0 / 2147483648}},
+		{"f32le:8000:1", float32LE, []float64{0.25, -1.5}},
+		{"f64be:8000:1", float64BE, []float64{0.1, -3}},
+		// interleaved, with a truncated trailing frame dropped
+		{"s16le:8000:2", []byte{0x00, 0x40, 0x00, 0xc0, 0x00, 0x20, 0x00, 0xe0, 0x00},
+			[]float64{0.5, -0.5, 0.25, -0.25}},
+	}
+	for _, test := range tests {
+		format, err := ParseRawFormat(test.format)
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		stream, err := format.NewStreamFromReader(bytes.NewReader(test.data), ReadOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if (stream.Info().NumChannels != format.NumChannels) ||
+			(stream.Info().BitsPerSample != 8*format.BytesPerSample()) {
+			t.Errorf("%s: unexpected info: %+v", test.format, stream.Info())
+		}
+		found, err := readAllFrames(stream)
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if len(found) != len(test.expected) {
+			t.Errorf("%s: expected %d samples, found %d", test.format, len(test.expected), len(found))
+			continue
+		}
+		for i := range test.expected {
+			if found[i] != test.expected[i] {
+				t.Errorf("%s: sample %d: expected %g, found %g", test.format, i, test.expected[i], found[i])
+			}
+		}
+	}
+
+	// the number of frames of a file is derived from its size
+	path := filepath.Join(t.TempDir(), "raw.pcm")
+	err := os.WriteFile(path, make([]byte, 6*100+5), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := RawFormat{SampleRate: 8000, NumChannels: 2, Encoding: RawEncodingS24}
+	stream, err := OpenFileStream(path, ReadOptions{}, format.NewStreamFromReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if stream.Info().NumFrames != 100 {
+		t.Errorf("expected 100 frames, found %d", stream.Info().NumFrames)
+	}
+}
@@ -0,0 +1,272 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/gabriel-vasile/mimetype"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sniffSize is the number of leading bytes examined to detect the format.
+const sniffSize = 3072
+
+// Decoder describes an audio format that can be read by ReadFromFile and OpenFile.
+type Decoder struct {
+	Name       string
+	MimeTypes  []string                 // MIME types as reported by mimetype, e.g. "audio/flac"
+	Extensions []string                 // file extensions including the dot, e.g. ".flac"
+	Detect     func(header []byte) bool // magic-byte sniffer, optional
+	Read       func(reader io.Reader, options ReadOptions) (Source, error)
+	Open       func(reader io.Reader, options ReadOptions) (StreamSource, error)
+}
+
+var (
+	decodersMutex sync.RWMutex
+	decoders      = make([]*Decoder, 0)
+)
+
+// RegisterDecoder adds a decoder. Decoders registered later take precedence.
+func RegisterDecoder(decoder *Decoder) {
+	decodersMutex.Lock()
+	defer decodersMutex.Unlock()
+
+	decoders = append([]*Decoder{decoder}, decoders...)
+}
+
+// Decoders returns the registered decoders, in order of precedence.
+func Decoders() []*Decoder {
+	decodersMutex.RLock()
+	defer decodersMutex.RUnlock()
+
+	return append([]*Decoder{}, decoders...)
+}
+
+// DetectDecoder finds the decoder for a file from its leading bytes, trying magic-byte sniffers first, then the MIME
+// type, then the file extension of name.
+func DetectDecoder(header []byte, name string) (*Decoder, error) {
+	registered := Decoders()
+	for _, decoder := range registered {
+		if (decoder.Detect != nil) && decoder.Detect(header) {
+			return decoder, nil
+		}
+	}
+
+	mimeType := mimetype.Detect(header)
+	for _, decoder := range registered {
+		for _, decoderMimeType := range decoder.MimeTypes {
+			if mimeType.Is(decoderMimeType) {
+				return decoder, nil
+			}
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != "" {
+		for _, decoder := range registered {
+			for _, decoderExt := range decoder.Extensions {
+				if ext == strings.ToLower(decoderExt) {
+					return decoder, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported MIME type: %s", mimeType.String())
+}
+
+// detectDecoderFromReader detects the decoder using bytes peeked from reader, which are not consumed.
+func detectDecoderFromReader(reader *bufio.Reader, name string) (*Decoder, error) {
+	header, err := reader.Peek(sniffSize)
+	if (err != nil) && (err != io.EOF) && (err != bufio.ErrBufferFull) {
+		return nil, err
+	}
+	return DetectDecoder(header, name)
+}
+
+func ReadFromFile(path string) (Source, error) {
+	return ReadFromFileWithOptions(path, ReadOptions{})
+}
+
+func ReadFromFileWithOptions(path string, options ReadOptions) (Source, error) {
+	return ReadFile(path, options, func(reader io.Reader, options ReadOptions) (Source, error) {
+		return ReadFromReaderWithOptions(reader, path, options)
+	})
+}
+
+func ReadFromReader(reader io.Reader, name string) (Source, error) {
+	return ReadFromReaderWithOptions(reader, name, ReadOptions{})
+}
+
+// ReadFromReaderWithOptions reads audio from reader, such as stdin, detecting the format from its leading bytes.
+// name is used to detect the format from the file extension, and may be empty.
+func ReadFromReaderWithOptions(reader io.Reader, name string, options ReadOptions) (Source, error) {
+	bufReader := bufio.NewReaderSize(reader, sniffSize)
+	decoder, err := detectDecoderFromReader(bufReader, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.Read(bufReader, options)
+}
+
+// bufferedReadSeeker buffers reads from an io.ReadSeeker, discarding the buffer when seeking.
+type bufferedReadSeeker struct {
+	*bufio.Reader
+	readSeeker io.ReadSeeker
+}
+
+func newBufferedReadSeeker(readSeeker io.ReadSeeker) *bufferedReadSeeker {
+	return &bufferedReadSeeker{
+		Reader:     bufio.NewReaderSize(readSeeker, sniffSize),
+		readSeeker: readSeeker,
+	}
+}
+
+func (b *bufferedReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekCurrent {
+		if offset == 0 {
+			// report the position without discarding the buffer
+			pos, err := b.readSeeker.Seek(0, io.SeekCurrent)
+			return pos - int64(b.Reader.Buffered()), err
+		}
+		offset -= int64(b.Reader.Buffered())
+	}
+	pos, err := b.readSeeker.Seek(offset, whence)
+	b.Reader.Reset(b.readSeeker)
+	return pos, err
+}
+
+// isSeekable reports whether readSeeker can actually seek; stdin implements io.Seeker even when it is a pipe.
+func isSeekable(readSeeker io.ReadSeeker) bool {
+	_, err := readSeeker.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+// fileStream closes the file it was opened from with the stream.
+type fileStream struct {
+	StreamSource
+	file *os.File
+}
+
+// ReadFile reads a whole file with the reader of a format, e.g. ReadFile(path, options, ReadFLACFromReader).
+func ReadFile[S Source](path string, options ReadOptions, read func(io.Reader, ReadOptions) (S, error)) (S, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		var src S
+		return src, err
+	}
+	defer f.Close()
+
+	return read(f, options)
+}
+
+// OpenFileStream opens a stream over a file with the stream constructor of a format, e.g.
+// OpenFileStream(path, options, NewFLACStreamFromReader). The stream reads the file through a buffered io.ReadSeeker,
+// so that it can seek if the format supports it, and closes the file when it is closed.
+func OpenFileStream[S StreamSource](path string, options ReadOptions,
+	open func(io.Reader, ReadOptions) (S, error)) (StreamSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := open(newBufferedReadSeeker(f), options)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &fileStream{
+		StreamSource: stream,
+		file:         f,
+	}, nil
+}
+
+// sourceReader adapts the reader of a format to Decoder.Read.
+func sourceReader[S Source](read func(io.Reader, ReadOptions) (S, error)) func(io.Reader, ReadOptions) (Source, error) {
+	return func(reader io.Reader, options ReadOptions) (Source, error) {
+		src, err := read(reader, options)
+		if err != nil {
+			// avoid a non-nil Source holding a nil pointer
+			return nil, err
+		}
+		return src, nil
+	}
+}
+
+// streamOpener adapts the stream constructor of a format to Decoder.Open.
+func streamOpener[S StreamSource](open func(io.Reader, ReadOptions) (S, error)) func(io.Reader,
+	ReadOptions) (StreamSource, error) {
+	return func(reader io.Reader, options ReadOptions) (StreamSource, error) {
+		stream, err := open(reader, options)
+		if err != nil {
+			return nil, err
+		}
+		return stream, nil
+	}
+}
+
+func OpenFile(path string) (StreamSource, error) {
+	return OpenFileWithOptions(path, ReadOptions{})
+}
+
+func OpenFileWithOptions(path string, options ReadOptions) (StreamSource, error) {
+	return OpenFileStream(path, options, func(reader io.Reader, options ReadOptions) (StreamSource, error) {
+		return OpenReaderWithOptions(reader, path, options)
+	})
+}
+
+func OpenReader(reader io.Reader, name string) (StreamSource, error) {
+	return OpenReaderWithOptions(reader, name, ReadOptions{})
+}
+
+// OpenReaderWithOptions opens a stream over reader, such as stdin, detecting the format from its leading bytes.
+// name is used to detect the format from the file extension, and may be empty. Closing the stream does not close
+// reader.
+//
+// If reader is seekable, such as a file, the stream is opened over a buffered io.ReadSeeker, so that decoders that
+// support it can seek.
+func OpenReaderWithOptions(reader io.Reader, name string, options ReadOptions) (StreamSource, error) {
+	var bufReader *bufio.Reader
+	var src io.Reader
+	readSeeker, ok := reader.(io.ReadSeeker)
+	if ok && isSeekable(readSeeker) {
+		bufReadSeeker, ok := readSeeker.(*bufferedReadSeeker)
+		if !ok {
+			bufReadSeeker = newBufferedReadSeeker(readSeeker)
+		}
+		bufReader = bufReadSeeker.Reader
+		src = bufReadSeeker
+	} else {
+		bufReader = bufio.NewReaderSize(reader, sniffSize)
+		src = bufReader
+	}
+
+	decoder, err := detectDecoderFromReader(bufReader, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.Open(src, options)
+}
+
+func (fs *fileStream) SeekFrame(frame int64) error {
+	seeker, ok := fs.StreamSource.(Seeker)
+	if !ok {
+		return ErrSeekUnsupported
+	}
+	return seeker.SeekFrame(frame)
+}
+
+func (fs *fileStream) Close() error {
+	err := fs.StreamSource.Close()
+	if err != nil {
+		_ = fs.file.Close()
+		return err
+	}
+	return fs.file.Close()
+}
@@ -0,0 +1,186 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestDetectDecoder(t *testing.T) {
+	vorbis, err := os.ReadFile("testdata/chained.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w64 := []byte("riff\x2e\x91\xcf\x11\xa5\xd6\x28\xdb\x04\xc1\x00\x00\x50\x00\x00\x00\x00\x00\x00\x00" +
+		"wave\xf3\xac\xd3\x11\x8c\xd1\x00\xc0\x4f\x8e\xdb\x8a")
+	tests := []struct {
+		name     string
+		header   []byte
+		fileName string
+		expected string
+	}{
+		{"RIFF", wavFile(1, 8000, []int16{0}, -1), "", "wav"},
+		{"RIFX", []byte("RIFX\x00\x00\x00\x24WAVEfmt "), "", "wav"},
+		{"W64", w64, "", "w64"},
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22"), "", "flac"},
+		{"Ogg Vorbis", vorbis, "", "vorbis"},
+		{"AIFF", []byte("FORM\x00\x00\x00\x00AIFFCOMM"), "", "aiff"},
+		{"AIFF-C", []byte("FORM\x00\x00\x00\x00AIFCFVER"), "", "aiff"},
+		// MPEG-1 layer III, 128 kbit/s, 44.1 kHz
+		{"MP3 frame", []byte{0xff, 0xfb, 0x90, 0x64, 0, 0, 0, 0}, "", "mp3"},
+		// left to the MIME type
+		{"MP3 with ID3v2", []byte("ID3\x04\x00\x00\x00\x00\x00\x00\xff\xfb\x90\x64"), "", "mp3"},
+		// the file extension, as the header is not recognised
+		{"extension", []byte("unknown"), "/path/to/audio.FLAC", "flac"},
+		{"extension .oga", []byte("unknown"), "audio.oga", "vorbis"},
+		{"extension .aifc", []byte("unknown"), "audio.aifc", "aiff"},
+		// the header takes precedence over the file extension
+		{"header and extension", []byte("fLaC\x00\x00\x00\x22"), "audio.wav", "flac"},
+	}
+	for _, test := range tests {
+		decoder, err := DetectDecoder(test.header, test.fileName)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if decoder.Name != test.expected {
+			t.Errorf("%s: expected decoder %s, found %s", test.name, test.expected, decoder.Name)
+		}
+	}
+
+	_, err = DetectDecoder([]byte("unknown"), "audio.txt")
+	if err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+// testSource is a Source of silence.
+type testSource struct {
+	info Info
+}
+
+func (src *testSource) Info() Info {
+	return src.info
+}
+
+func (src *testSource) Frames() [][]float64 {
+	frames := make([][]float64, src.info.NumFrames)
+	for i := range frames {
+		frames[i] = make([]float64, src.info.NumChannels)
+	}
+	return frames
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder(&Decoder{
+		Name:       "test",
+		Extensions: []string{".test"},
+		Detect: func(header []byte) bool {
+			return bytes.HasPrefix(header, []byte("TEST"))
+		},
+		Read: func(reader io.Reader, options ReadOptions) (Source, error) {
+			header := make([]byte, 4)
+			_, err := io.ReadFull(reader, header)
+			if err != nil {
+				return nil, err
+			}
+			if string(header) != "TEST" {
+				return nil, errors.New("the sniffed bytes were consumed")
+			}
+			return &testSource{info: Info{NumChannels: 1, SampleRate: 100, NumFrames: 3}}, nil
+		},
+		Open: func(reader io.Reader, options ReadOptions) (StreamSource, error) {
+			return NewSourceStream(&testSource{info: Info{NumChannels: 2, SampleRate: 100, NumFrames: 5}}), nil
+		},
+	})
+
+	// decoders registered later take precedence
+	if Decoders()[0].Name != "test" {
+		t.Errorf("expected the test decoder first, found %s", Decoders()[0].Name)
+	}
+	src, err := ReadFromReader(bytes.NewReader([]byte("TEST")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(src.Frames()) != 3 {
+		t.Errorf("expected 3 frames, found %d", len(src.Frames()))
+	}
+	stream, err := OpenReader(bytes.NewReader([]byte("data")), "audio.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Info().NumChannels != 2 {
+		t.Errorf("expected 2 channels, found %d", stream.Info().NumChannels)
+	}
+	// the built-in decoders are unaffected
+	decoder, err := DetectDecoder([]byte("fLaC"), "")
+	if (err != nil) || (decoder.Name != "flac") {
+		t.Errorf("expected the flac decoder, found %v, %v", decoder, err)
+	}
+}
+
+// pipeReader is an io.ReadSeeker that cannot seek, as os.Stdin when it is a pipe.
+type pipeReader struct {
+	io.Reader
+}
+
+func (p *pipeReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("illegal seek")
+}
+
+func TestOpenReaderFromPipe(t *testing.T) {
+	for _, path := range []string{"testdata/seektable.flac", "testdata/chained.ogg", "../wave/testdata/ms_adpcm.wav"} {
+		stream, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := readAllFrames(stream)
+		_ = stream.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the format is sniffed from peeked bytes, which are still decoded
+		readers := map[string]func() io.Reader{
+			"reader": func() io.Reader {
+				return struct{ io.Reader }{bytes.NewReader(file)}
+			},
+			"pipe": func() io.Reader {
+				return &pipeReader{bytes.NewReader(file)}
+			},
+		}
+		for name, newReader := range readers {
+			stream, err := OpenReader(newReader(), "")
+			if err != nil {
+				t.Fatalf("%s from a %s: %v", path, name, err)
+			}
+			found, err := readAllFrames(stream)
+			if err != nil {
+				t.Fatalf("%s from a %s: %v", path, name, err)
+			}
+			if len(found) != len(expected) {
+				t.Fatalf("%s from a %s: expected %d samples, found %d", path, name, len(expected), len(found))
+			}
+			for i := range expected {
+				if found[i] != expected[i] {
+					t.Fatalf("%s from a %s: sample %d: expected %g, found %g", path, name, i, expected[i], found[i])
+				}
+			}
+
+			src, err := ReadFromReader(newReader(), "")
+			if err != nil {
+				t.Fatalf("%s from a %s: %v", path, name, err)
+			}
+			if int(src.Info().NumFrames)*src.Info().NumChannels != len(expected) {
+				t.Errorf("%s from a %s: expected %d samples, found %d frames", path, name, len(expected),
+					src.Info().NumFrames)
+			}
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+type ResampleQuality int
+
+const (
+	ResampleQualityLow ResampleQuality = iota
+	ResampleQualityMedium
+	ResampleQualityHigh
+)
+
+// maxResamplePhases limits the number of precomputed filter phases. Conversion ratios needing more phases
+// interpolate between adjacent phases.
+const maxResamplePhases = 1024
+
+// resampleQualityParams are the parameters of the Kaiser-windowed sinc filter for each quality.
+var resampleQualityParams = map[ResampleQuality]struct {
+	zeroCrossings int     // zero crossings of the sinc on each side
+	beta          float64 // Kaiser window shape
+	rolloff       float64 // cutoff, relative to the lower Nyquist frequency
+}{
+	ResampleQualityLow:    {8, 5.0, 0.85},
+	ResampleQualityMedium: {16, 7.0, 0.91},
+	ResampleQualityHigh:   {32, 9.0, 0.95},
+}
+
+var resampleQualityNames = map[string]ResampleQuality{
+	"low":    ResampleQualityLow,
+	"medium": ResampleQualityMedium,
+	"high":   ResampleQualityHigh,
+}
+
+// ResampleStream converts the sample rate of a StreamSource with a polyphase windowed-sinc filter.
+type ResampleStream struct {
+	src          StreamSource
+	info         Info
+	inputRate    int64
+	outputRate   int64
+	halfLength   int         // filter taps on each side of the output position
+	numPhases    int64       // phases per input sample
+	filters      [][]float64 // numPhases + 1 filters of 2 * halfLength taps
+	history      [][]float64 // input samples of each channel, starting at input frame base
+	base         int64
+	readBuffer   []float64
+	inputFrames  int64 // total input frames, -1 until the end of the input is reached
+	outputFrame  int64
+	outputFrames int64 // total output frames, -1 if unknown
+}
+
+// ResampledSource is an in-memory Source converted to another sample rate.
+type ResampledSource struct {
+	info   Info
+	buffer *PlanarBuffer
+}
+
+// ParseResampleQuality parses a quality name: low, medium or high.
+func ParseResampleQuality(s string) (ResampleQuality, error) {
+	quality, ok := resampleQualityNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown resample quality: %s", s)
+	}
+	return quality, nil
+}
+
+// Resample converts the sample rate of an in-memory Source.
+func Resample(src Source, sampleRate int, quality ResampleQuality) (*ResampledSource, error) {
+	sampleType := SampleTypeFloat64
+	planarSource, ok := src.(PlanarSource)
+	if ok {
+		sampleType = planarSource.Buffer().SampleType()
+	}
+
+	stream, err := NewResampleStream(NewSourceStream(src), sampleRate, quality)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := readAll(stream, sampleType)
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	info.NumFrames = int64(buffer.NumFrames())
+	return &ResampledSource{
+		info:   info,
+		buffer: buffer,
+	}, nil
+}
+
+func (r *ResampledSource) Info() Info {
+	return r.info
+}
+
+func (r *ResampledSource) Frames() [][]float64 {
+	return r.buffer.Frames()
+}
+
+func (r *ResampledSource) Buffer() *PlanarBuffer {
+	return r.buffer
+}
+
+// NewResampleStream returns a stream that converts src to the specified sample rate. Closing it closes src.
+func NewResampleStream(src StreamSource, sampleRate int, quality ResampleQuality) (*ResampleStream, error) {
+	info := src.Info()
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", sampleRate)
+	}
+	if info.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid input sample rate: %d", info.SampleRate)
+	}
+	params, ok := resampleQualityParams[quality]
+	if !ok {
+		return nil, fmt.Errorf("unknown resample quality: %d", quality)
+	}
+
+	inputRate := int64(info.SampleRate)
+	outputRate := int64(sampleRate)
+	g := gcd(inputRate, outputRate)
+	numPhases := outputRate / g
+	if numPhases > maxResamplePhases {
+		numPhases = maxResamplePhases
+	}
+
+	// when downsampling, the cutoff is below the input Nyquist frequency, widening the filter
+	cutoff := params.rolloff * math.Min(1, float64(outputRate)/float64(inputRate))
+	halfLength := int(math.Ceil(float64(params.zeroCrossings) / cutoff))
+	filters := make([][]float64, numPhases+1)
+	for p := range filters {
+		filters[p] = newResampleFilter(float64(p)/float64(numPhases), halfLength, cutoff, params.beta)
+	}
+
+	r := &ResampleStream{
+		src:          src,
+		info:         info,
+		inputRate:    inputRate,
+		outputRate:   outputRate,
+		halfLength:   halfLength,
+		numPhases:    numPhases,
+		filters:      filters,
+		history:      make([][]float64, info.NumChannels),
+		base:         -int64(halfLength - 1),
+		readBuffer:   make([]float64, readBufferFrames*info.NumChannels),
+		inputFrames:  -1,
+		outputFrames: -1,
+	}
+	for j := range r.history {
+		// samples before the start are silent
+		r.history[j] = make([]float64, halfLength-1)
+	}
+	r.info.SampleRate = sampleRate
+	r.info.NumFrames = 0
+	if info.NumFrames > 0 {
+		r.outputFrames = r.outputLength(info.NumFrames)
+		r.info.NumFrames = r.outputFrames
+	}
+	return r, nil
+}
+
+// newResampleFilter returns the taps for an output position frac input samples after an input sample. Tap k applies
+// to the input sample k - halfLength + 1 samples from it. The taps are normalised to unity gain at DC.
+func newResampleFilter(frac float64, halfLength int, cutoff float64, beta float64) []float64 {
+	taps := make([]float64, 2*halfLength)
+	sum := 0.0
+	for k := range taps {
+		t := float64(k-halfLength+1) - frac
+		x := t / float64(halfLength)
+		if math.Abs(x) >= 1 {
+			continue
+		}
+		taps[k] = sinc(cutoff*t) * besselI0(beta*math.Sqrt(1-x*x))
+		sum += taps[k]
+	}
+	for k := range taps {
+		taps[k] /= sum
+	}
+	return taps
+}
+
+func (r *ResampleStream) Info() Info {
+	return r.info
+}
+
+// outputLength returns the number of output frames for the specified number of input frames.
+func (r *ResampleStream) outputLength(inputFrames int64) int64 {
+	return (inputFrames*r.outputRate + r.inputRate - 1) / r.inputRate
+}
+
+func (r *ResampleStream) ReadFrames(buffer []float64) (int, error) {
+	numChannels := r.info.NumChannels
+	n := 0
+	for n < len(buffer)/numChannels {
+		if (r.outputFrames >= 0) && (r.outputFrame >= r.outputFrames) {
+			break
+		}
+
+		// output frame i is at input position i * inputRate / outputRate
+		position := r.outputFrame * r.inputRate
+		index := position / r.outputRate
+		phase := (position % r.outputRate) * r.numPhases
+		phaseIndex := phase / r.outputRate
+		weight := float64(phase%r.outputRate) / float64(r.outputRate)
+
+		end := index + int64(r.halfLength) + 1
+		err := r.fill(end)
+		if err != nil {
+			return n, err
+		}
+		if r.inputFrames >= 0 {
+			// the declared length of the input may be missing or wrong
+			outputFrames := r.outputLength(r.inputFrames)
+			if (r.outputFrames < 0) || (outputFrames < r.outputFrames) {
+				r.outputFrames = outputFrames
+				continue
+			}
+		}
+
+		start := int(index - int64(r.halfLength) + 1 - r.base)
+		filter := r.filters[phaseIndex]
+		for j := 0; j < numChannels; j++ {
+			samples := r.history[j][start:]
+			value := dot(filter, samples)
+			if weight > 0 {
+				// interpolate between adjacent phases
+				value += weight * (dot(r.filters[phaseIndex+1], samples) - value)
+			}
+			buffer[n*numChannels+j] = value
+		}
+		r.outputFrame++
+		n++
+
+		r.discard(index - int64(r.halfLength) + 1)
+	}
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// fill reads input until the history extends to input frame end. Past the end of the input, it is padded with
+// silence.
+func (r *ResampleStream) fill(end int64) error {
+	numChannels := r.info.NumChannels
+	for r.base+int64(len(r.history[0])) < end {
+		if r.inputFrames >= 0 {
+			for j := range r.history {
+				r.history[j] = append(r.history[j], 0)
+			}
+			continue
+		}
+
+		numFrames, err := r.src.ReadFrames(r.readBuffer)
+		if err == io.EOF {
+			r.inputFrames = r.base + int64(len(r.history[0]))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for j := range r.history {
+			for i := 0; i < numFrames; i++ {
+				r.history[j] = append(r.history[j], r.readBuffer[i*numChannels+j])
+			}
+		}
+	}
+	return nil
+}
+
+// discard drops the history before input frame start once enough has accumulated.
+func (r *ResampleStream) discard(start int64) {
+	drop := int(start - r.base)
+	if drop < readBufferFrames {
+		return
+	}
+	for j := range r.history {
+		remaining := copy(r.history[j], r.history[j][drop:])
+		r.history[j] = r.history[j][:remaining]
+	}
+	r.base = start
+}
+
+// SeekFrame seeks the source to the first input frame that output frame contributes to. If the source cannot seek, it
+// is read and discarded instead, which requires that nothing has been read.
+func (r *ResampleStream) SeekFrame(frame int64) error {
+	if (frame < 0) || ((r.outputFrames >= 0) && (frame > r.outputFrames)) {
+		return fmt.Errorf("cannot seek to frame %d of %d", frame, r.outputFrames)
+	}
+
+	start := frame*r.inputRate/r.outputRate - int64(r.halfLength) + 1
+	srcStart := start
+	if srcStart < 0 {
+		srcStart = 0
+	}
+	err := ErrSeekUnsupported
+	seeker, ok := r.src.(Seeker)
+	if ok {
+		err = seeker.SeekFrame(srcStart)
+	}
+	if err == ErrSeekUnsupported {
+		if r.outputFrame > 0 {
+			return ErrSeekUnsupported
+		}
+		err = Seek(r.src, srcStart)
+	}
+	if err != nil {
+		return err
+	}
+
+	for j := range r.history {
+		// samples before the start are silent
+		r.history[j] = make([]float64, srcStart-start)
+	}
+	r.base = start
+	r.inputFrames = -1
+	r.outputFrame = frame
+	return nil
+}
+
+func (r *ResampleStream) Close() error {
+	return r.src.Close()
+}
+
+func dot(a []float64, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 is the zeroth-order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum := 1.0
+	term := 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-16 {
+			break
+		}
+	}
+	return sum
+}
+
+func gcd(a int64, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
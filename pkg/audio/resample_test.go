@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// resampleWAV resamples a mono WAVE file of the samples, returning the stream and the frames read.
+func resampleWAV(t *testing.T, inputRate int, samples []int16, outputRate int,
+	quality ResampleQuality) (*ResampleStream, []float64) {
+	src, err := NewWAVStreamFromReader(bytes.NewReader(wavFile(1, inputRate, samples, -1)), ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := NewResampleStream(src, outputRate, quality)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := readAllFrames(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream, frames
+}
+
+// fitSinusoid returns the amplitudes of the sine and cosine at the frequency that best fit frames first to last, by
+// least squares.
+func fitSinusoid(frames []float64, first int, last int, sampleRate int, frequency float64) (float64, float64) {
+	var ss, sc, cc, ys, yc float64
+	for i := first; i < last; i++ {
+		y := frames[i]
+		sin, cos := math.Sincos(2 * math.Pi * frequency * float64(i) / float64(sampleRate))
+		ss += sin * sin
+		sc += sin * cos
+		cc += cos * cos
+		ys += y * sin
+		yc += y * cos
+	}
+	det := ss*cc - sc*sc
+	return (ys*cc - yc*sc) / det, (yc*ss - ys*sc) / det
+}
+
+func TestResampleStreamLength(t *testing.T) {
+	tests := []struct {
+		inputRate  int
+		outputRate int
+		numFrames  int
+		expected   int64
+	}{
+		{44100, 48000, 44100, 48000},
+		{48000, 44100, 48000, 44100},
+		{44100, 48000, 1000, 1089}, // 1088.4 rounded up
+		{48000, 44100, 1000, 919},  // 918.75 rounded up
+		{8000, 16000, 1, 2},
+		{16000, 8000, 1, 1},
+		{22050, 8000, 12345, 4479}, // 4478.9 rounded up
+	}
+	for _, test := range tests {
+		stream, frames := resampleWAV(t, test.inputRate, make([]int16, test.numFrames), test.outputRate,
+			ResampleQualityMedium)
+		if stream.Info().NumFrames != test.expected {
+			t.Errorf("%d frames from %d to %d Hz: expected %d frames, found %d in Info", test.numFrames,
+				test.inputRate, test.outputRate, test.expected, stream.Info().NumFrames)
+		}
+		if int64(len(frames)) != test.expected {
+			t.Errorf("%d frames from %d to %d Hz: expected %d frames, read %d", test.numFrames, test.inputRate,
+				test.outputRate, test.expected, len(frames))
+		}
+	}
+}
+
+func TestResampleStreamPassband(t *testing.T) {
+	rates := [][2]int{{44100, 48000}, {48000, 44100}, {8000, 44100}, {48000, 16000}}
+	for _, quality := range []ResampleQuality{ResampleQualityLow, ResampleQualityMedium, ResampleQualityHigh} {
+		for _, rate := range rates {
+			inputRate, outputRate := rate[0], rate[1]
+			// a frequency well within the passband of the lower rate
+			frequency := 0.2 * math.Min(float64(inputRate), float64(outputRate))
+			_, frames := resampleWAV(t, inputRate, sine(1, inputRate, inputRate, 0.5, frequency), outputRate, quality)
+
+			// without the transients at either end
+			sinAmplitude, cosAmplitude := fitSinusoid(frames, outputRate/10, len(frames)-outputRate/10, outputRate,
+				frequency)
+			amplitude := math.Hypot(sinAmplitude, cosAmplitude)
+			phase := math.Atan2(cosAmplitude, sinAmplitude)
+
+			gain := 20 * math.Log10(amplitude/0.5)
+			if math.Abs(gain) > 0.01 {
+				t.Errorf("quality %d, %d to %d Hz: expected a gain of 0 dB at %g Hz, found %g dB", quality,
+					inputRate, outputRate, frequency, gain)
+			}
+			// the output is aligned with the input
+			if math.Abs(phase) > 1e-3 {
+				t.Errorf("quality %d, %d to %d Hz: expected no phase shift at %g Hz, found %g rad", quality,
+					inputRate, outputRate, frequency, phase)
+			}
+		}
+	}
+}
+
+func TestResampleStreamStopband(t *testing.T) {
+	// above the Nyquist frequency of the output, the tone must not alias
+	_, frames := resampleWAV(t, 48000, sine(1, 48000, 48000, 0.5, 23000), 44100, ResampleQualityHigh)
+	frames = frames[4410 : len(frames)-4410]
+	sum := 0.0
+	for _, val := range frames {
+		sum += val * val
+	}
+	rms := math.Sqrt(sum / float64(len(frames)))
+	attenuation := 20 * math.Log10(rms*math.Sqrt2/0.5)
+	if attenuation > -60 {
+		t.Errorf("expected an attenuation of at least 60 dB at 23 kHz, found %g dB", attenuation)
+	}
+}
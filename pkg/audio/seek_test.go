@@ -0,0 +1,95 @@
+package audio
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSeekFrame(t *testing.T) {
+	samples := sine(2, 8000, 3000, 0.5, 440)
+	for i := 1; i < len(samples); i += 2 {
+		samples[i] = -samples[i] / 2
+	}
+	pcm := wavFile(2, 8000, samples, -1)
+	tests := []struct {
+		name   string
+		open   func() (StreamSource, error)
+		frames []int64
+	}{
+		{"PCM WAV", func() (StreamSource, error) {
+			return NewWAVStreamFromReader(bytes.NewReader(pcm), ReadOptions{})
+		}, []int64{1, 1500, 2999, 3000, 0}},
+		// 256-byte stereo blocks of 249 and 244 frames
+		{"IMA ADPCM WAV", func() (StreamSource, error) {
+			return OpenFileStream("../wave/testdata/ima_adpcm.wav", ReadOptions{}, NewWAVStreamFromReader)
+		}, []int64{300, 498, 997, 1000, 0}},
+		{"MS ADPCM WAV", func() (StreamSource, error) {
+			return OpenFileStream("../wave/testdata/ms_adpcm.wav", ReadOptions{}, NewWAVStreamFromReader)
+		}, []int64{300, 488, 990, 1000, 0}},
+		// 3000 frames in blocks of 256, with seek points at 0, 1024 and 2048
+		{"FLAC", func() (StreamSource, error) {
+			return OpenFileStream("testdata/seektable.flac", ReadOptions{}, NewFLACStreamFromReader)
+		}, []int64{100, 1024, 1500, 2048, 2100, 2999, 3000, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream, err := test.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := readAllFrames(stream)
+			_ = stream.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stream, err = test.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			numChannels := stream.Info().NumChannels
+			for _, frame := range test.frames {
+				// seeks directly, without the fallback of Seek that reads and discards
+				err = stream.(Seeker).SeekFrame(frame)
+				if err != nil {
+					t.Fatalf("frame %d: %v", frame, err)
+				}
+				found, err := readAllFrames(stream)
+				if err != nil {
+					t.Fatalf("frame %d: %v", frame, err)
+				}
+				skipped := expected[frame*int64(numChannels):]
+				if len(found) != len(skipped) {
+					t.Fatalf("frame %d: expected %d frames, found %d", frame, len(skipped)/numChannels,
+						len(found)/numChannels)
+				}
+				for i := range skipped {
+					if found[i] != skipped[i] {
+						t.Fatalf("frame %d: sample %d: expected %g, found %g", frame, i, skipped[i], found[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFLACSeekTable(t *testing.T) {
+	f, err := os.Open("testdata/seektable.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stream, err := NewFLACStreamFromReader(f, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if len(stream.seekPoints) != 3 {
+		t.Fatalf("expected 3 seek points, found %d", len(stream.seekPoints))
+	}
+	if stream.Info().NumFrames != 3000 {
+		t.Fatalf("expected 3000 frames, found %d", stream.Info().NumFrames)
+	}
+}
@@ -5,32 +5,37 @@ import (
 	"github.com/gabriel-vasile/mimetype"
 	"io"
 	"os"
+	"strings"
 )
 
 type Source interface {
 	Info() Info
+	// Frames returns the samples frame-major, i.e. indexed by frame, then by channel.
 	Frames() [][]float64
 }
 
+type ReadOptions struct {
+	SampleType SampleType // sample type of PlanarSource buffers
+	VerifyMD5  bool       // verify the decoded audio against the MD5 signature, if the format has one
+	Lenient    bool       // return the audio decoded before an error instead of failing
+}
+
 type Info struct {
 	NumChannels   int
 	SampleRate    int
 	BitsPerSample int
+	ChannelNames  []string // speaker name of each channel (e.g. "FL", "LFE"), "" if unknown
+	NumFrames     int64    // 0 if unknown
 }
 
-func ReadFromFile(path string) (Source, error) {
-	mimeType, err := DetectMimeTypeFromFile(path)
-	if err != nil {
-		return nil, err
+// ChannelIndex returns the index of the channel with the specified speaker name (case-insensitive).
+func (info Info) ChannelIndex(name string) (int, error) {
+	for i, channelName := range info.ChannelNames {
+		if (channelName != "") && strings.EqualFold(channelName, name) {
+			return i, nil
+		}
 	}
-
-	if mimeType == "audio/wav" {
-		return ReadWAVFromFile(path)
-	} else if mimeType == "audio/flac" {
-		return ReadFLACFromFile(path)
-	}
-
-	return nil, fmt.Errorf("unsupported MIME type: %s", mimeType)
+	return -1, fmt.Errorf("unknown channel name: %s", name)
 }
 
 func DetectMimeTypeFromFile(path string) (string, error) {
@@ -50,4 +55,4 @@ func DetectMimeTypeFromReader(reader io.Reader) (string, error) {
 	}
 
 	return fileMimeType.String(), nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,167 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSeekUnsupported is returned by Seeker.SeekFrame when the underlying reader cannot seek.
+var ErrSeekUnsupported = errors.New("seek not supported")
+
+// StreamSource is a pull-based Source that decodes frames on demand, so that memory use does not grow with the
+// length of the audio.
+type StreamSource interface {
+	Info() Info
+	// ReadFrames reads up to len(buffer) / Info().NumChannels frames into buffer, interleaved. Returns the number of
+	// frames read, or io.EOF when there are no more frames.
+	ReadFrames(buffer []float64) (int, error)
+	Close() error
+}
+
+// ChannelReader is implemented by streams that can read a single channel without deinterleaving every frame.
+type ChannelReader interface {
+	// ReadChannel reads up to len(dst) samples of the channel into dst, advancing the stream by as many frames.
+	// Returns the number of samples read, or io.EOF when there are no more frames.
+	ReadChannel(channel int, dst []float64) (int, error)
+}
+
+// Seeker is implemented by streams that can be repositioned without decoding the preceding frames.
+type Seeker interface {
+	// SeekFrame positions the stream so that the next frame read is frame. Returns ErrSeekUnsupported if the
+	// underlying reader cannot seek.
+	SeekFrame(frame int64) error
+}
+
+type sourceStream struct {
+	src    Source
+	buffer *PlanarBuffer
+	frames [][]float64
+	pos    int
+}
+
+// NewSourceStream returns a StreamSource that reads the samples of an in-memory Source. The samples of a
+// PlanarSource are read from its buffer directly.
+func NewSourceStream(src Source) StreamSource {
+	planarSource, ok := src.(PlanarSource)
+	if ok {
+		return &sourceStream{
+			src:    src,
+			buffer: planarSource.Buffer(),
+		}
+	}
+	return &sourceStream{
+		src:    src,
+		frames: src.Frames(),
+	}
+}
+
+func (s *sourceStream) Info() Info {
+	return s.src.Info()
+}
+
+func (s *sourceStream) numFrames() int {
+	if s.buffer != nil {
+		return s.buffer.NumFrames()
+	}
+	return len(s.frames)
+}
+
+func (s *sourceStream) ReadFrames(buffer []float64) (int, error) {
+	numFrames := s.numFrames()
+	if s.pos >= numFrames {
+		return 0, io.EOF
+	}
+	numChannels := s.src.Info().NumChannels
+	n := 0
+	for ; (n < len(buffer)/numChannels) && (s.pos < numFrames); n++ {
+		if s.buffer != nil {
+			for j := 0; j < numChannels; j++ {
+				buffer[n*numChannels+j] = s.buffer.Sample(j, s.pos)
+			}
+		} else {
+			copy(buffer[n*numChannels:(n+1)*numChannels], s.frames[s.pos])
+		}
+		s.pos++
+	}
+	return n, nil
+}
+
+func (s *sourceStream) ReadChannel(channel int, dst []float64) (int, error) {
+	numFrames := s.numFrames()
+	if s.pos >= numFrames {
+		return 0, io.EOF
+	}
+	n := 0
+	if s.buffer != nil {
+		n = s.buffer.CopyChannel(channel, s.pos, dst)
+	} else {
+		for ; (n < len(dst)) && (s.pos+n < numFrames); n++ {
+			dst[n] = s.frames[s.pos+n][channel]
+		}
+	}
+	s.pos += n
+	return n, nil
+}
+
+func (s *sourceStream) SeekFrame(frame int64) error {
+	if (frame < 0) || (frame > int64(s.numFrames())) {
+		return fmt.Errorf("cannot seek to frame %d of %d", frame, s.numFrames())
+	}
+	s.pos = int(frame)
+	return nil
+}
+
+func (s *sourceStream) Close() error {
+	return nil
+}
+
+// readAll reads the remaining frames of a stream into a planar buffer.
+func readAll(stream StreamSource, sampleType SampleType) (*PlanarBuffer, error) {
+	info := stream.Info()
+	buffer := NewPlanarBuffer(info.NumChannels, sampleType)
+	buffer.growForHeader(info.NumFrames)
+
+	readBuffer := make([]float64, readBufferFrames*info.NumChannels)
+	for {
+		n, err := stream.ReadFrames(readBuffer)
+		buffer.AppendInterleaved(readBuffer, n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer, nil
+}
+
+// Seek positions a stream so that the next frame read is frame. Streams that implement Seeker are repositioned
+// directly. Other streams, which must not have been read from, are read and discarded up to frame.
+func Seek(stream StreamSource, frame int64) error {
+	seeker, ok := stream.(Seeker)
+	if ok {
+		err := seeker.SeekFrame(frame)
+		if err != ErrSeekUnsupported {
+			return err
+		}
+	}
+
+	numChannels := stream.Info().NumChannels
+	readBuffer := make([]float64, readBufferFrames*numChannels)
+	for remaining := frame; remaining > 0; {
+		maxFrames := int64(readBufferFrames)
+		if maxFrames > remaining {
+			maxFrames = remaining
+		}
+		n, err := stream.ReadFrames(readBuffer[:maxFrames*int64(numChannels)])
+		if err == io.EOF {
+			return fmt.Errorf("cannot seek to frame %d of %d", frame, frame-remaining)
+		}
+		if err != nil {
+			return err
+		}
+		remaining -= int64(n)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jfreymuth/oggvorbis"
+	"io"
+	"strings"
+)
+
+// vorbisChannelNames are the speaker names of the channels for each Vorbis channel count, as defined by the Vorbis I
+// specification.
+var vorbisChannelNames = [][]string{
+	{"FC"},
+	{"FL", "FR"},
+	{"FL", "FC", "FR"},
+	{"FL", "FR", "BL", "BR"},
+	{"FL", "FC", "FR", "BL", "BR"},
+	{"FL", "FC", "FR", "BL", "BR", "LFE"},
+	{"FL", "FC", "FR", "SL", "SR", "BC", "LFE"},
+	{"FL", "FC", "FR", "SL", "SR", "BL", "BR", "LFE"},
+}
+
+func init() {
+	RegisterDecoder(&Decoder{
+		Name:       "vorbis",
+		MimeTypes:  []string{"audio/ogg"},
+		Extensions: []string{".ogg", ".oga"},
+		Detect:     isOggVorbis,
+		Read:       sourceReader(ReadOggVorbisFromReader),
+		Open:       streamOpener(NewOggVorbisStreamFromReader),
+	})
+}
+
+// VorbisComments is the comment header of a Vorbis stream. Field names are upper-cased, as they are case-insensitive.
+type VorbisComments struct {
+	Vendor   string
+	Comments map[string][]string
+}
+
+type OggVorbisSource struct {
+	info     Info
+	comments []*VorbisComments
+	buffer   *PlanarBuffer
+}
+
+// OggVorbisStream decodes Ogg Vorbis, including chained streams, which must all have the same channel count and
+// sample rate.
+type OggVorbisStream struct {
+	reader   *bufio.Reader
+	options  ReadOptions
+	decoder  *oggvorbis.Reader
+	info     Info
+	comments []*VorbisComments
+	buffer   []float32
+	// maxReadFrames keeps each read below the decoder's block size, since larger reads decode past the end of the
+	// stream into the headers of the next chained stream
+	maxReadFrames int
+	done          bool
+}
+
+func ReadOggVorbisFromReader(reader io.Reader, options ReadOptions) (*OggVorbisSource, error) {
+	stream, err := NewOggVorbisStreamFromReader(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readAll(stream, options.SampleType)
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	info.NumFrames = int64(buffer.NumFrames())
+	return &OggVorbisSource{
+		info:     info,
+		comments: stream.Comments(),
+		buffer:   buffer,
+	}, nil
+}
+
+func (o *OggVorbisSource) Info() Info {
+	return o.info
+}
+
+// Comments returns the comment header of each chained stream.
+func (o *OggVorbisSource) Comments() []*VorbisComments {
+	return o.comments
+}
+
+func (o *OggVorbisSource) Frames() [][]float64 {
+	return o.buffer.Frames()
+}
+
+func (o *OggVorbisSource) Buffer() *PlanarBuffer {
+	return o.buffer
+}
+
+func NewOggVorbisStreamFromReader(reader io.Reader, options ReadOptions) (*OggVorbisStream, error) {
+	stream := &OggVorbisStream{
+		// hides io.Seeker, so that the decoder does not seek to the end for the length, which is wrong for chained
+		// streams
+		reader:  bufio.NewReader(reader),
+		options: options,
+	}
+	err := stream.nextChain()
+	if err != nil {
+		return nil, err
+	}
+	stream.info = Info{
+		NumChannels:   stream.decoder.Channels(),
+		SampleRate:    stream.decoder.SampleRate(),
+		BitsPerSample: 32,
+	}
+	if stream.info.NumChannels <= len(vorbisChannelNames) {
+		stream.info.ChannelNames = vorbisChannelNames[stream.info.NumChannels-1]
+	}
+	return stream, nil
+}
+
+func (o *OggVorbisStream) Info() Info {
+	return o.info
+}
+
+// Comments returns the comment header of each chained stream read so far.
+func (o *OggVorbisStream) Comments() []*VorbisComments {
+	return o.comments
+}
+
+func (o *OggVorbisStream) ReadFrames(buffer []float64) (int, error) {
+	numChannels := o.info.NumChannels
+	numFrames := len(buffer) / numChannels
+	if numFrames > o.maxReadFrames {
+		numFrames = o.maxReadFrames
+	}
+	size := numFrames * numChannels
+	if len(o.buffer) < size {
+		o.buffer = make([]float32, size)
+	}
+	for !o.done {
+		n, err := o.decoder.Read(o.buffer[:size])
+		if n > 0 {
+			for i := 0; i < n; i++ {
+				buffer[i] = float64(o.buffer[i])
+			}
+			return n / numChannels, nil
+		}
+		if err == io.EOF {
+			err = o.nextChain()
+			if err == io.EOF {
+				o.done = true
+				break
+			}
+		}
+		if err != nil {
+			o.done = true
+			if o.options.Lenient {
+				break
+			}
+			return 0, err
+		}
+	}
+	return 0, io.EOF
+}
+
+// nextChain starts decoding the next chained stream. Returns io.EOF if there are no more streams.
+func (o *OggVorbisStream) nextChain() error {
+	_, err := o.reader.Peek(1)
+	if err == io.EOF {
+		return io.EOF
+	}
+	page, _ := o.reader.Peek(28 + 29)
+	maxReadFrames, ok := vorbisMaxReadFrames(page)
+	if !ok {
+		return fmt.Errorf("Ogg Vorbis stream %d: identification header not found", len(o.comments))
+	}
+	decoder, err := oggvorbis.NewReader(o.reader)
+	if err != nil {
+		return fmt.Errorf("Ogg Vorbis stream %d: %w", len(o.comments), err)
+	}
+	if (o.decoder != nil) &&
+		((decoder.Channels() != o.info.NumChannels) || (decoder.SampleRate() != o.info.SampleRate)) {
+		return fmt.Errorf("Ogg Vorbis stream %d: format differs from the first stream", len(o.comments))
+	}
+	o.decoder = decoder
+	o.maxReadFrames = maxReadFrames
+	o.comments = append(o.comments, newVorbisComments(decoder))
+	return nil
+}
+
+func (o *OggVorbisStream) Close() error {
+	return nil
+}
+
+func newVorbisComments(decoder *oggvorbis.Reader) *VorbisComments {
+	header := decoder.CommentHeader()
+	comments := &VorbisComments{
+		Vendor:   header.Vendor,
+		Comments: make(map[string][]string),
+	}
+	for _, comment := range header.Comments {
+		name, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+		name = strings.ToUpper(name)
+		comments.Comments[name] = append(comments.Comments[name], value)
+	}
+	return comments
+}
+
+// vorbisMaxReadFrames returns the largest read that stays below the long block size, from the first page of a stream,
+// which holds only the identification header.
+func vorbisMaxReadFrames(page []byte) (int, bool) {
+	if (len(page) < 28+29) || (page[26] != 1) || !isOggVorbis(page) {
+		return 0, false
+	}
+	blockSize1 := 1 << (page[28+28] >> 4)
+	return blockSize1/2 - 1, true
+}
+
+func isOggVorbis(header []byte) bool {
+	return (len(header) >= 35) && (string(header[0:4]) == "OggS") && (string(header[28:35]) == "\x01vorbis")
+}
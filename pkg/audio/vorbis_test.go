@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"os"
+	"testing"
+)
+
+func TestOggVorbisChained(t *testing.T) {
+	// two links of the same 1 s of mono audio, with different serial numbers and comments
+	f, err := os.Open("testdata/chained.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stream, err := NewOggVorbisStreamFromReader(f, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := readAllFrames(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 2*44100 {
+		t.Fatalf("expected %d frames, found %d", 2*44100, len(frames))
+	}
+	for i := 0; i < 44100; i++ {
+		if frames[44100+i] != frames[i] {
+			t.Fatalf("frame %d: expected %g as in the first link, found %g", 44100+i, frames[i], frames[44100+i])
+		}
+	}
+
+	comments := stream.Comments()
+	if len(comments) != 2 {
+		t.Fatalf("expected the comments of 2 links, found %d", len(comments))
+	}
+	expected := []map[string][]string{
+		{"TITLE": {"First"}, "ARTIST": {"Someone"}},
+		{"TITLE": {"Second", "Alternative"}},
+	}
+	for i := range expected {
+		if comments[i].Vendor == "" {
+			t.Errorf("link %d: expected a vendor", i)
+		}
+		if len(comments[i].Comments) != len(expected[i]) {
+			t.Errorf("link %d: expected comments %v, found %v", i, expected[i], comments[i].Comments)
+			continue
+		}
+		for name, values := range expected[i] {
+			found := comments[i].Comments[name]
+			if len(found) != len(values) {
+				t.Errorf("link %d: expected %s %q, found %q", i, name, values, found)
+				continue
+			}
+			for k := range values {
+				if found[k] != values[k] {
+					t.Errorf("link %d: expected %s %q, found %q", i, name, values, found)
+				}
+			}
+		}
+	}
+}
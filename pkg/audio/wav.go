@@ -3,41 +3,153 @@ package audio
 import (
 	"github.com/ngyewch/go-spectrogram/pkg/wave"
 	"io"
-	"os"
 )
 
+const (
+	// readBufferFrames is the number of frames decoded at a time when reading a whole file.
+	readBufferFrames = 4096
+	// maxPreallocatedSamples limits the buffer capacity allocated up front based on the (untrusted) header, over all
+	// channels.
+	maxPreallocatedSamples = 1 << 20
+)
+
+func init() {
+	RegisterDecoder(&Decoder{
+		Name:       "wav",
+		MimeTypes:  []string{"audio/wav"},
+		Extensions: []string{".wav", ".bwf"},
+		Detect:     isWAV,
+		Read:       sourceReader(ReadWAVFromReader),
+		Open:       streamOpener(NewWAVStreamFromReader),
+	})
+	RegisterDecoder(&Decoder{
+		Name:       "w64",
+		Extensions: []string{".w64"},
+		Detect:     wave.IsWave64,
+		Read:       sourceReader(ReadW64FromReader),
+		Open:       streamOpener(NewW64StreamFromReader),
+	})
+}
+
 type WAVSource struct {
-	wav *wave.Wave
+	wav    *wave.Wave
+	buffer *PlanarBuffer
+}
+
+type WAVStream struct {
+	decoder *wave.Decoder
 }
 
-func ReadWAVFromFile(path string) (*WAVSource, error) {
-	f, err := os.Open(path)
+func ReadWAVFromReader(reader io.Reader, options ReadOptions) (*WAVSource, error) {
+	decoder, err := wave.NewDecoder(reader)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	return ReadWAVFromReader(f)
+	return readWAVSource(decoder, options)
 }
 
-func ReadWAVFromReader(reader io.Reader) (*WAVSource, error) {
-	wav, err := wave.ReadWaveFromReader(reader, false)
+func ReadW64FromReader(reader io.Reader, options ReadOptions) (*WAVSource, error) {
+	decoder, err := wave.NewWave64Decoder(reader)
 	if err != nil {
 		return nil, err
 	}
+	return readWAVSource(decoder, options)
+}
+
+// readWAVSource decodes the data sub-chunk directly into a PlanarBuffer.
+func readWAVSource(decoder *wave.Decoder, options ReadOptions) (*WAVSource, error) {
+	wav := decoder.Wave()
+	numChannels := wav.Fmt.NumChannels
+	buffer := NewPlanarBuffer(numChannels, options.SampleType)
+	buffer.growForHeader(decoder.NumFrames())
+
+	readBuffer := make([]float64, readBufferFrames*numChannels)
+	for {
+		n, err := decoder.ReadFrames(readBuffer)
+		buffer.AppendInterleaved(readBuffer, n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &WAVSource{
-		wav: wav,
+		wav:    wav,
+		buffer: buffer,
 	}, nil
 }
 
 func (wavSource *WAVSource) Info() Info {
+	return wavInfo(wavSource.wav.Fmt, int64(wavSource.buffer.NumFrames()))
+}
+
+func (wavSource *WAVSource) Frames() [][]float64 {
+	return wavSource.buffer.Frames()
+}
+
+func (wavSource *WAVSource) Buffer() *PlanarBuffer {
+	return wavSource.buffer
+}
+
+func NewWAVStreamFromReader(reader io.Reader, options ReadOptions) (*WAVStream, error) {
+	decoder, err := wave.NewDecoder(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &WAVStream{
+		decoder: decoder,
+	}, nil
+}
+
+func NewW64StreamFromReader(reader io.Reader, options ReadOptions) (*WAVStream, error) {
+	decoder, err := wave.NewWave64Decoder(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &WAVStream{
+		decoder: decoder,
+	}, nil
+}
+
+func (wavStream *WAVStream) Info() Info {
+	return wavInfo(wavStream.decoder.Wave().Fmt, wavStream.decoder.NumFrames())
+}
+
+func (wavStream *WAVStream) ReadFrames(buffer []float64) (int, error) {
+	return wavStream.decoder.ReadFrames(buffer)
+}
+
+// SeekFrame seeks by byte offset, or to the containing block for ADPCM.
+func (wavStream *WAVStream) SeekFrame(frame int64) error {
+	err := wavStream.decoder.SeekFrame(frame)
+	if err == wave.ErrNotSeekable {
+		return ErrSeekUnsupported
+	}
+	return err
+}
+
+func (wavStream *WAVStream) Close() error {
+	return nil
+}
+
+func wavInfo(waveFmt *wave.WaveFmt, numFrames int64) Info {
 	return Info{
-		NumChannels:   wavSource.wav.Fmt.NumChannels,
-		SampleRate:    wavSource.wav.Fmt.SampleRate,
-		BitsPerSample: wavSource.wav.Fmt.BitsPerSample,
+		NumChannels:   waveFmt.NumChannels,
+		SampleRate:    waveFmt.SampleRate,
+		BitsPerSample: waveFmt.BitsPerSample,
+		ChannelNames:  waveFmt.ChannelNames(),
+		NumFrames:     numFrames,
 	}
 }
 
-func (wavSource *WAVSource) Frames() [][]float64 {
-	return wavSource.wav.Frames
+// isWAV matches RIFF, RIFX, RF64 and BW64 WAVE files.
+func isWAV(header []byte) bool {
+	if len(header) < 12 {
+		return false
+	}
+	chunkId := string(header[0:4])
+	return ((chunkId == "RIFF") || (chunkId == "RIFX") || (chunkId == "RF64") || (chunkId == "BW64")) &&
+		(string(header[8:12]) == "WAVE")
 }
@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadRF64(t *testing.T) {
+	for _, id := range []string{"RF64", "BW64"} {
+		// the RIFF header of a 16-bit PCM file, rewritten with the sizes in a ds64 sub-chunk
+		riff := wavFile(1, 8000, []int16{1, -2, 3}, -1)
+		ds64 := binary.LittleEndian.AppendUint64(nil, 0) // the RIFF size, set below
+		ds64 = binary.LittleEndian.AppendUint64(ds64, 6)
+		ds64 = binary.LittleEndian.AppendUint64(ds64, 3)
+		ds64 = binary.LittleEndian.AppendUint32(ds64, 0)
+		file := append([]byte(id), 0xff, 0xff, 0xff, 0xff)
+		file = append(file, "WAVEds64"...)
+		file = binary.LittleEndian.AppendUint32(file, uint32(len(ds64)))
+		file = append(file, ds64...)
+		file = append(file, riff[12:36]...)
+		file = append(file, "data"...)
+		file = append(file, 0xff, 0xff, 0xff, 0xff)
+		file = append(file, riff[44:]...)
+		binary.LittleEndian.PutUint64(file[20:], uint64(len(file)-8))
+
+		decoder, err := DetectDecoder(file, "")
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if decoder.Name != "wav" {
+			t.Errorf("%s: expected the wav decoder, found %s", id, decoder.Name)
+		}
+		src, err := ReadFromReader(bytes.NewReader(file), "")
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		frames := src.Frames()
+		if (len(frames) != 3) || (frames[1][0] != -2.0/32767) {
+			t.Errorf("%s: unexpected frames %v", id, frames)
+		}
+	}
+}
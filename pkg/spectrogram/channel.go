@@ -0,0 +1,145 @@
+package spectrogram
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ngyewch/go-spectrogram/pkg/audio"
+	"io"
+	"strings"
+)
+
+// ChannelMode selects how the channels are combined into the analysed signal.
+type ChannelMode int
+
+const (
+	ChannelModeSingle   ChannelMode = iota // the channel selected by Channel
+	ChannelModeAverage                     // the average of all channels
+	ChannelModeWeighted                    // the sum of the channels weighted by ChannelWeights
+	ChannelModeMid                         // L + R, of the first two channels
+	ChannelModeSide                        // L - R, of the first two channels
+	ChannelModePowerSum                    // the sum of the power spectra of all channels
+	ChannelModeAll                         // every channel, separately
+)
+
+var channelModeNames = map[string]ChannelMode{
+	"single":    ChannelModeSingle,
+	"average":   ChannelModeAverage,
+	"weighted":  ChannelModeWeighted,
+	"mid":       ChannelModeMid,
+	"side":      ChannelModeSide,
+	"power-sum": ChannelModePowerSum,
+	"all":       ChannelModeAll,
+}
+
+// ParseChannelMode parses a channel mode name: single, average, weighted, mid, side, power-sum or all.
+func ParseChannelMode(s string) (ChannelMode, error) {
+	mode, ok := channelModeNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown channel mode: %s", s)
+	}
+	return mode, nil
+}
+
+// channelWeights returns the weights of each channel for each signal to analyse. For ChannelModePowerSum, the spectra
+// of the signals are summed by power.
+func channelWeights(options SpectrogramOptions, numChannels int) ([][]float64, error) {
+	unit := func(channel int) []float64 {
+		weights := make([]float64, numChannels)
+		weights[channel] = 1
+		return weights
+	}
+
+	switch options.ChannelMode {
+	case ChannelModeSingle:
+		channel := int(options.Channel)
+		if channel < 0 || channel >= numChannels {
+			return nil, errors.New("invalid channel number")
+		}
+		return [][]float64{unit(channel)}, nil
+	case ChannelModeAverage:
+		weights := make([]float64, numChannels)
+		for j := range weights {
+			weights[j] = 1 / float64(numChannels)
+		}
+		return [][]float64{weights}, nil
+	case ChannelModeWeighted:
+		if len(options.ChannelWeights) != numChannels {
+			return nil, fmt.Errorf("expected %d channel weights, found %d", numChannels, len(options.ChannelWeights))
+		}
+		return [][]float64{append([]float64{}, options.ChannelWeights...)}, nil
+	case ChannelModeMid, ChannelModeSide:
+		if numChannels < 2 {
+			return nil, errors.New("mid and side require at least 2 channels")
+		}
+		weights := make([]float64, numChannels)
+		weights[0] = 1
+		weights[1] = 1
+		if options.ChannelMode == ChannelModeSide {
+			weights[1] = -1
+		}
+		return [][]float64{weights}, nil
+	case ChannelModePowerSum, ChannelModeAll:
+		signals := make([][]float64, numChannels)
+		for j := range signals {
+			signals[j] = unit(j)
+		}
+		return signals, nil
+	}
+	return nil, fmt.Errorf("unknown channel mode: %d", options.ChannelMode)
+}
+
+// readSignals reads len(dst[0]) frames from the stream, storing in dst[i] the sum of the channels weighted by
+// weights[i]. Returns fewer frames only at the end of the stream.
+func readSignals(stream audio.StreamSource, weights [][]float64, dst [][]float64, readBuffer []float64) (int, error) {
+	if len(weights) == 1 {
+		channel := unitChannel(weights[0])
+		if channel >= 0 {
+			return readChannel(stream, channel, dst[0], readBuffer)
+		}
+	}
+
+	numChannels := stream.Info().NumChannels
+	numFrames := len(dst[0])
+	total := 0
+	for total < numFrames {
+		maxFrames := numFrames - total
+		if maxFrames > len(readBuffer)/numChannels {
+			maxFrames = len(readBuffer) / numChannels
+		}
+		n, err := stream.ReadFrames(readBuffer[:maxFrames*numChannels])
+		for s, signalWeights := range weights {
+			signal := dst[s][total : total+n]
+			for i := range signal {
+				frame := readBuffer[i*numChannels : (i+1)*numChannels]
+				sum := 0.0
+				for j, weight := range signalWeights {
+					sum += weight * frame[j]
+				}
+				signal[i] = sum
+			}
+		}
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// unitChannel returns the channel selected by weights if it has a weight of 1 and the others 0, or -1.
+func unitChannel(weights []float64) int {
+	channel := -1
+	for j, weight := range weights {
+		if weight == 0 {
+			continue
+		}
+		if (weight != 1) || (channel >= 0) {
+			return -1
+		}
+		channel = j
+	}
+	return channel
+}
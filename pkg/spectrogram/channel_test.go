@@ -0,0 +1,151 @@
+package spectrogram
+
+import (
+	"github.com/mjibson/go-dsp/window"
+	"math"
+	"testing"
+)
+
+// combinedSource returns a mono source of the frames of src combined by the weights.
+func combinedSource(src *memorySource, weights []float64) *memorySource {
+	frames := make([][]float64, len(src.frames))
+	for i, frame := range src.frames {
+		sum := 0.0
+		for j, weight := range weights {
+			sum += weight * frame[j]
+		}
+		frames[i] = []float64{sum}
+	}
+	return &memorySource{
+		info:   memoryInfo(1, src.info.SampleRate, len(frames)),
+		frames: frames,
+	}
+}
+
+// compareSpectrograms compares the columns of two spectrograms, with a tolerance relative to the largest bin.
+func compareSpectrograms(t *testing.T, name string, found [][]float64, expected [][]float64) {
+	if len(found) != len(expected) {
+		t.Errorf("%s: expected %d columns, found %d", name, len(expected), len(found))
+		return
+	}
+	peak := 0.0
+	for _, column := range expected {
+		for _, v := range column {
+			peak = math.Max(peak, math.Abs(v))
+		}
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(found[i][j]-expected[i][j]) > 1e-12*peak {
+				t.Errorf("%s: column %d, bin %d: expected %g, found %g", name, i, j, expected[i][j], found[i][j])
+				return
+			}
+		}
+	}
+}
+
+func TestChannelModes(t *testing.T) {
+	source := &memorySource{
+		info:   memoryInfo(3, 8000, 4000),
+		frames: sineFrames(8000, 4000, []float64{0.5, 0.25, 0.125}, []float64{440, 1250, 3000}),
+	}
+	options := SpectrogramOptions{
+		FftSamples:     512,
+		WindowFunction: window.Hann,
+		Scaling:        ScalingMagnitude,
+		Workers:        1,
+	}
+
+	tests := []struct {
+		name     string
+		mode     ChannelMode
+		weights  []float64
+		expected []float64
+	}{
+		{"average", ChannelModeAverage, nil, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+		{"weighted", ChannelModeWeighted, []float64{0.5, -2, 0}, []float64{0.5, -2, 0}},
+		// of the first two channels only
+		{"mid", ChannelModeMid, nil, []float64{1, 1, 0}},
+		{"side", ChannelModeSide, nil, []float64{1, -1, 0}},
+	}
+	for _, test := range tests {
+		options.ChannelMode = ChannelModeSingle
+		expected, err := GenerateSpectrogram(combinedSource(source, test.expected), options)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		options.ChannelMode = test.mode
+		options.ChannelWeights = test.weights
+		found, err := GenerateSpectrogram(source, options)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		compareSpectrograms(t, test.name, found.Data, expected.Data)
+	}
+
+	// the power spectra of the channels are summed
+	options.Scaling = ScalingPower
+	options.ChannelMode = ChannelModeSingle
+	options.ChannelWeights = nil
+	expected := make([][]float64, 0)
+	for channel := uint(0); channel < 3; channel++ {
+		options.Channel = channel
+		spectrogram, err := GenerateSpectrogram(source, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, column := range spectrogram.Data {
+			if channel == 0 {
+				expected = append(expected, make([]float64, len(column)))
+			}
+			for j, v := range column {
+				expected[i][j] += v
+			}
+		}
+	}
+	options.ChannelMode = ChannelModePowerSum
+	found, err := GenerateSpectrogram(source, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSpectrograms(t, "power-sum", found.Data, expected)
+}
+
+func TestChannelModeErrors(t *testing.T) {
+	stereo := &memorySource{
+		info:   memoryInfo(2, 8000, 1000),
+		frames: sineFrames(8000, 1000, []float64{0.5, 0.5}, []float64{440, 440}),
+	}
+	mono := combinedSource(stereo, []float64{1, 0})
+	tests := []struct {
+		name    string
+		source  *memorySource
+		options SpectrogramOptions
+	}{
+		{"channel", stereo, SpectrogramOptions{ChannelMode: ChannelModeSingle, Channel: 2}},
+		{"too few weights", stereo, SpectrogramOptions{ChannelMode: ChannelModeWeighted, ChannelWeights: []float64{1}}},
+		{"no weights", stereo, SpectrogramOptions{ChannelMode: ChannelModeWeighted}},
+		{"mono mid", mono, SpectrogramOptions{ChannelMode: ChannelModeMid}},
+		{"mono side", mono, SpectrogramOptions{ChannelMode: ChannelModeSide}},
+		{"unknown", stereo, SpectrogramOptions{ChannelMode: ChannelMode(100)}},
+	}
+	for _, test := range tests {
+		test.options.FftSamples = 256
+		test.options.WindowFunction = window.Hann
+		_, err := GenerateSpectrogram(test.source, test.options)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	for name, expected := range channelModeNames {
+		found, err := ParseChannelMode(name)
+		if (err != nil) || (found != expected) {
+			t.Errorf("%q: expected %d, found %d (%v)", name, expected, found, err)
+		}
+	}
+	_, err := ParseChannelMode("left")
+	if err == nil {
+		t.Errorf("expected an error for an unknown channel mode")
+	}
+}
@@ -0,0 +1,63 @@
+package spectrogram
+
+// columnJob is a window of each signal, for which a worker computes the column of each panel.
+type columnJob struct {
+	samples [][]float64
+	columns [][]float64
+}
+
+// columnWorker computes columns with its own buffers and FFT plan.
+type columnWorker struct {
+	plan      *fftPlan
+	window    []float64 // shared by the workers, read only
+	buffer    []float64
+	power     [][]float64
+	numPanels int
+	scaler    binScaler
+}
+
+func newColumnWorker(fftSamples int, window []float64, numPanels int, scaler binScaler) *columnWorker {
+	power := make([][]float64, numPanels)
+	for k := range power {
+		power[k] = make([]float64, fftSamples/2)
+	}
+	return &columnWorker{
+		plan:      newFFTPlan(fftSamples),
+		window:    window,
+		buffer:    make([]float64, len(window)),
+		power:     power,
+		numPanels: numPanels,
+		scaler:    scaler,
+	}
+}
+
+// compute returns the column of each panel, scaled by the scaler. Each signal is a panel, or the spectra of the signals
+// are summed by power.
+func (w *columnWorker) compute(samples [][]float64) [][]float64 {
+	for k := range w.power {
+		for j := range w.power[k] {
+			w.power[k][j] = 0
+		}
+	}
+	for i := range samples {
+		panelPower := w.power[i%w.numPanels]
+		for j, val := range samples[i] {
+			w.buffer[j] = val * w.window[j]
+		}
+		// zero-padded past the window
+		fftResult := w.plan.transform(w.buffer)
+		for j := range panelPower {
+			val := fftResult[j]
+			panelPower[j] += (real(val) * real(val)) + (imag(val) * imag(val))
+		}
+	}
+
+	columns := make([][]float64, len(w.power))
+	for k := range w.power {
+		columns[k] = make([]float64, len(w.power[k]))
+		for j := range w.power[k] {
+			columns[k][j] = w.scaler.scale(w.power[k][j])
+		}
+	}
+	return columns
+}
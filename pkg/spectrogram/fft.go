@@ -0,0 +1,113 @@
+package spectrogram
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fftPlan computes the FFT of real input of a fixed length, with the twiddle factors computed once. Lengths that are
+// not a power of 2 use Bluestein's algorithm over a radix-2 FFT. A plan holds its own buffers, so it must not be used
+// by more than one goroutine at a time.
+type fftPlan struct {
+	n        int
+	twiddles []complex128 // exp(-2πik/m) for k < m/2, where m is len(buffer)
+	reversed []int        // the bit-reversed index of each index of buffer
+	buffer   []complex128
+	chirp    []complex128 // exp(-πik²/n) for k < n, for Bluestein's algorithm
+	filter   []complex128 // the FFT of the conjugate chirp, for Bluestein's algorithm
+}
+
+func newFFTPlan(n int) *fftPlan {
+	m := n
+	if !IsPowerOfTwo(uint(n)) {
+		m = 1 << bits.Len(uint(2*n-2))
+	}
+
+	plan := &fftPlan{
+		n:        n,
+		twiddles: make([]complex128, m/2),
+		reversed: make([]int, m),
+		buffer:   make([]complex128, m),
+	}
+	for k := range plan.twiddles {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(k) / float64(m))
+		plan.twiddles[k] = complex(cos, sin)
+	}
+	shift := bits.UintSize - bits.Len(uint(m-1))
+	for i := range plan.reversed {
+		plan.reversed[i] = int(bits.Reverse(uint(i)) >> shift)
+	}
+
+	if m != n {
+		plan.chirp = make([]complex128, n)
+		for k := range plan.chirp {
+			// k² mod 2n keeps the angle small, as exp(-πik²/n) has a period of 2n in k²
+			kk := (int64(k) * int64(k)) % int64(2*n)
+			sin, cos := math.Sincos(-math.Pi * float64(kk) / float64(n))
+			plan.chirp[k] = complex(cos, sin)
+		}
+		plan.filter = make([]complex128, m)
+		for k := range plan.chirp {
+			plan.filter[k] = cmplx.Conj(plan.chirp[k])
+			if k > 0 {
+				plan.filter[m-k] = plan.filter[k]
+			}
+		}
+		plan.radix2(plan.filter)
+	}
+
+	return plan
+}
+
+// transform returns the FFT of input, zero-padded to the length of the plan. The result is overwritten by the next
+// call.
+func (p *fftPlan) transform(input []float64) []complex128 {
+	for i := range p.buffer {
+		p.buffer[i] = 0
+	}
+	if p.chirp == nil {
+		for i, val := range input {
+			p.buffer[i] = complex(val, 0)
+		}
+		p.radix2(p.buffer)
+		return p.buffer
+	}
+
+	for i, val := range input {
+		p.buffer[i] = complex(val, 0) * p.chirp[i]
+	}
+	p.radix2(p.buffer)
+	for i := range p.buffer {
+		// the inverse FFT, as the conjugate of the FFT of the conjugate
+		p.buffer[i] = cmplx.Conj(p.buffer[i] * p.filter[i])
+	}
+	p.radix2(p.buffer)
+	scale := complex(1/float64(len(p.buffer)), 0)
+	for k := range p.chirp {
+		p.buffer[k] = cmplx.Conj(p.buffer[k]) * scale * p.chirp[k]
+	}
+	return p.buffer[:p.n]
+}
+
+// radix2 computes the FFT of x in place, using the iterative radix-2 Cooley-Tukey algorithm. len(x) is that of the
+// buffer of the plan.
+func (p *fftPlan) radix2(x []complex128) {
+	for i, j := range p.reversed {
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	m := len(x)
+	for size := 2; size <= m; size <<= 1 {
+		half := size / 2
+		step := m / size
+		for start := 0; start < m; start += size {
+			for k := 0; k < half; k++ {
+				t := p.twiddles[k*step] * x[start+k+half]
+				x[start+k+half] = x[start+k] - t
+				x[start+k] += t
+			}
+		}
+	}
+}
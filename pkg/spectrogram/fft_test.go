@@ -0,0 +1,111 @@
+package spectrogram
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+func TestFFTPlan(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	// powers of 2, odd, prime and composite lengths, and inputs shorter than the plan
+	tests := []struct {
+		n           int
+		inputLength int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 3},
+		{4, 4},
+		{5, 5},
+		{7, 7},
+		{8, 8},
+		{12, 12},
+		{960, 960},
+		{1000, 1000},
+		{1024, 1024},
+		{4097, 4097},
+		{44100, 44100},
+		{1024, 700},
+		{1000, 333},
+		{4097, 1},
+	}
+	for _, test := range tests {
+		input := make([]float64, test.inputLength)
+		for i := range input {
+			input[i] = 2*random.Float64() - 1
+		}
+		padded := make([]float64, test.n)
+		copy(padded, input)
+		expected := fft.FFTReal(padded)
+
+		plan := newFFTPlan(test.n)
+		// a second transform must not depend on the first
+		plan.transform(padded)
+		found := plan.transform(input)
+		if len(found) != test.n {
+			t.Fatalf("n %d: expected %d bins, found %d", test.n, test.n, len(found))
+		}
+		scale := 0.0
+		for _, val := range expected {
+			scale = math.Max(scale, cmplx.Abs(val))
+		}
+		for k := range expected {
+			if cmplx.Abs(found[k]-expected[k]) > 1e-10*scale {
+				t.Fatalf("n %d, input length %d, bin %d: expected %v, found %v", test.n, test.inputLength, k,
+					expected[k], found[k])
+			}
+		}
+	}
+}
+
+func TestGenerateSpectrogramWorkers(t *testing.T) {
+	source := &memorySource{
+		info:   memoryInfo(2, 8000, 20000),
+		frames: sineFrames(8000, 20000, []float64{0.5, 0.25}, []float64{440, 1250}),
+	}
+	overlap := uint(300)
+	options := SpectrogramOptions{
+		ChannelMode:    ChannelModeAll,
+		FftSamples:     1000,
+		WindowSamples:  400,
+		Overlap:        &overlap,
+		WindowFunction: window.Hann,
+		Scaling:        ScalingAmplitude,
+	}
+
+	options.Workers = 1
+	expected, err := GenerateSpectrogram(source, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []uint{2, 3, 8} {
+		options.Workers = workers
+		found, err := GenerateSpectrogram(source, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found.ChannelData) != len(expected.ChannelData) {
+			t.Fatalf("%d workers: expected %d panels, found %d", workers, len(expected.ChannelData),
+				len(found.ChannelData))
+		}
+		for k := range expected.ChannelData {
+			if len(found.ChannelData[k]) != len(expected.ChannelData[k]) {
+				t.Fatalf("%d workers, panel %d: expected %d columns, found %d", workers, k,
+					len(expected.ChannelData[k]), len(found.ChannelData[k]))
+			}
+			for i := range expected.ChannelData[k] {
+				for j := range expected.ChannelData[k][i] {
+					if found.ChannelData[k][i][j] != expected.ChannelData[k][i][j] {
+						t.Fatalf("%d workers, panel %d, column %d, bin %d: expected %g, found %g", workers, k, i, j,
+							expected.ChannelData[k][i][j], found.ChannelData[k][i][j])
+					}
+				}
+			}
+		}
+	}
+}
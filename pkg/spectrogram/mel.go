@@ -0,0 +1,192 @@
+package spectrogram
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MelScale selects the formula converting frequencies to mels.
+type MelScale int
+
+const (
+	MelScaleSlaney MelScale = iota // linear below 1 kHz and logarithmic above, as in the Auditory Toolbox and librosa
+	MelScaleHTK                    // 2595 log10(1 + f / 700), as in HTK
+)
+
+var melScaleNames = map[string]MelScale{
+	"slaney": MelScaleSlaney,
+	"htk":    MelScaleHTK,
+}
+
+// ParseMelScale parses a mel scale name: slaney or htk.
+func ParseMelScale(s string) (MelScale, error) {
+	scale, ok := melScaleNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown mel scale: %s", s)
+	}
+	return scale, nil
+}
+
+type MelOptions struct {
+	NumMels       uint
+	MinFrequency  float64
+	MaxFrequency  *float64 // nil for half the sample rate
+	Scale         MelScale
+	AreaNormalize bool // divide each filter by its width in Hz, so that the filters have the same area
+}
+
+const (
+	slaneyMinLogHz  = 1000.0
+	slaneyMinLogMel = slaneyMinLogHz / (200.0 / 3)
+)
+
+var slaneyLogStep = math.Log(6.4) / 27
+
+func (scale MelScale) hzToMel(hz float64) float64 {
+	if scale == MelScaleHTK {
+		return 2595 * math.Log10(1+hz/700)
+	}
+	if hz < slaneyMinLogHz {
+		return hz / (200.0 / 3)
+	}
+	return slaneyMinLogMel + math.Log(hz/slaneyMinLogHz)/slaneyLogStep
+}
+
+func (scale MelScale) melToHz(mel float64) float64 {
+	if scale == MelScaleHTK {
+		return 700 * (math.Pow(10, mel/2595) - 1)
+	}
+	if mel < slaneyMinLogMel {
+		return mel * (200.0 / 3)
+	}
+	return slaneyMinLogHz * math.Exp(slaneyLogStep*(mel-slaneyMinLogMel))
+}
+
+// ToMel returns a spectrogram of NumMels bands, in the same unit, by applying a bank of triangular filters spaced
+// evenly in mels to the power of the bins. The dB scalings are limited to Floor. Returns an error if a band contains
+// no FFT bins. ToImage renders the bands at equal heights, so the vertical axis is mel-spaced.
+func (spectrogram *Spectrogram) ToMel(options MelOptions) (*Spectrogram, error) {
+	if spectrogram.MelFrequencies != nil {
+		return nil, errors.New("already a mel spectrogram")
+	}
+	if options.NumMels < 1 {
+		return nil, errors.New("NumMels must be at least 1")
+	}
+	if (options.Scale != MelScaleSlaney) && (options.Scale != MelScaleHTK) {
+		return nil, fmt.Errorf("unknown mel scale: %d", options.Scale)
+	}
+	fsOver2 := float64(spectrogram.SampleRate) / 2
+	maxFreq := fsOver2
+	if options.MaxFrequency != nil {
+		maxFreq = *options.MaxFrequency
+	}
+	if (maxFreq <= 0) || (maxFreq > fsOver2) {
+		return nil, errors.New("invalid MaxFrequency")
+	}
+	if (options.MinFrequency < 0) || (options.MinFrequency >= maxFreq) {
+		return nil, errors.New("MinFrequency must be at least 0 and less than MaxFrequency")
+	}
+
+	// NumMels + 2 edges; filter m rises from edge m to edge m+1 and falls to edge m+2
+	numMels := int(options.NumMels)
+	minMel := options.Scale.hzToMel(options.MinFrequency)
+	maxMel := options.Scale.hzToMel(maxFreq)
+	edges := make([]float64, numMels+2)
+	for m := range edges {
+		edges[m] = options.Scale.melToHz(minMel + (maxMel-minMel)*float64(m)/float64(numMels+1))
+	}
+
+	numBins := int(spectrogram.FftSamples / 2)
+	binWidth := float64(spectrogram.SampleRate) / float64(spectrogram.FftSamples)
+	filters := make([][]float64, numMels)
+	for m := range filters {
+		filters[m] = make([]float64, numBins)
+		lower, center, upper := edges[m], edges[m+1], edges[m+2]
+		for j := range filters[m] {
+			freq := float64(j) * binWidth
+			weight := math.Max(0, math.Min((freq-lower)/(center-lower), (upper-freq)/(upper-center)))
+			if options.AreaNormalize {
+				weight *= 2 / (upper - lower)
+			}
+			filters[m][j] = weight
+		}
+		if isZero(filters[m]) {
+			// as librosa warns of, the band falls between two bins
+			return nil, fmt.Errorf("mel band %d (%.1f Hz) contains no FFT bins, reduce NumMels or increase FftSamples",
+				m, center)
+		}
+	}
+
+	scaling := spectrogram.Scaling
+	transform := func(columns [][]float64) [][]float64 {
+		melColumns := make([][]float64, len(columns))
+		for i, column := range columns {
+			power := make([]float64, len(column))
+			for j, val := range column {
+				power[j] = toPower(scaling, val)
+			}
+			melColumns[i] = make([]float64, numMels)
+			for m, filter := range filters {
+				sum := 0.0
+				for j, weight := range filter {
+					if weight != 0 {
+						sum += weight * power[j]
+					}
+				}
+				melColumns[i][m] = fromPower(scaling, sum)
+				if scaling.isDecibels() && (spectrogram.Floor != nil) {
+					melColumns[i][m] = math.Max(melColumns[i][m], *spectrogram.Floor)
+				}
+			}
+		}
+		return melColumns
+	}
+
+	mel := *spectrogram
+	mel.MelFrequencies = edges[1 : numMels+1]
+	mel.Data = transform(spectrogram.Data)
+	if spectrogram.ChannelData != nil {
+		mel.ChannelData = make([][][]float64, len(spectrogram.ChannelData))
+		for k, panel := range spectrogram.ChannelData {
+			if k == 0 {
+				mel.ChannelData[k] = mel.Data
+			} else {
+				mel.ChannelData[k] = transform(panel)
+			}
+		}
+	}
+	return &mel, nil
+}
+
+func isZero(values []float64) bool {
+	for _, val := range values {
+		if val != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// toPower converts the value of a bin in the scaling to a quantity proportional to its power.
+func toPower(scaling Scaling, val float64) float64 {
+	switch scaling {
+	case ScalingMagnitude:
+		return val * val
+	case ScalingPower:
+		return val
+	}
+	return math.Pow(10, val/10)
+}
+
+// fromPower is the inverse of toPower. The reference of the dB scalings is preserved.
+func fromPower(scaling Scaling, power float64) float64 {
+	switch scaling {
+	case ScalingMagnitude:
+		return math.Sqrt(power)
+	case ScalingPower:
+		return power
+	}
+	return 10 * math.Log10(power)
+}
@@ -0,0 +1,257 @@
+package spectrogram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mjibson/go-dsp/window"
+)
+
+// melFilter is the weights of a filter from its first bin with a non-zero weight.
+type melFilter struct {
+	firstBin int
+	weights  []float64
+}
+
+// identitySpectrogram returns a spectrogram of ScalingPower whose column j has a power of 1 in bin j only, so that
+// column j of its mel spectrogram holds the weights of bin j.
+func identitySpectrogram(sampleRate uint, fftSamples uint) *Spectrogram {
+	data := make([][]float64, fftSamples/2)
+	for j := range data {
+		data[j] = make([]float64, fftSamples/2)
+		data[j][j] = 1
+	}
+	return &Spectrogram{
+		SampleRate:  sampleRate,
+		NumChannels: 1,
+		FftSamples:  fftSamples,
+		Scaling:     ScalingPower,
+		Unit:        ScalingPower.Unit(ReferenceFullScale),
+		Data:        data,
+	}
+}
+
+func TestMelFrequencies(t *testing.T) {
+	// librosa.mel_frequencies(n_mels=40), from its documentation, are the edges of 38 bands
+	expected := []float64{
+		0, 85.317, 170.635, 255.952, 341.269, 426.586, 511.904, 597.221, 682.538, 767.855, 853.173, 938.49,
+		1024.856, 1119.114, 1222.042, 1334.436, 1457.167, 1591.187, 1737.532, 1897.337, 2071.84, 2262.393, 2470.47,
+		2697.686, 2945.799, 3216.731, 3512.582, 3835.643, 4188.417, 4573.636, 4994.285, 5453.621, 5955.205, 6502.92,
+		7101.009, 7754.107, 8467.272, 9246.028, 10096.408, 11025,
+	}
+	mel, err := identitySpectrogram(22050, 2048).ToMel(MelOptions{NumMels: 38})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m, frequency := range mel.MelFrequencies {
+		if math.Abs(frequency-expected[m+1]) > 0.0005 {
+			t.Errorf("band %d: expected %g Hz, found %g Hz", m, expected[m+1], frequency)
+		}
+	}
+
+	// librosa.filters.mel(sr=22050, n_fft=2048)[0, 1], and with fmax=8000, from its documentation
+	mel, err = identitySpectrogram(22050, 2048).ToMel(MelOptions{NumMels: 128, AreaNormalize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(mel.Data[1][0]-0.016) > 0.0005 {
+		t.Errorf("expected a weight of 0.016, found %g", mel.Data[1][0])
+	}
+	maxFrequency := 8000.0
+	mel, err = identitySpectrogram(22050, 2048).ToMel(MelOptions{
+		NumMels:       128,
+		MaxFrequency:  &maxFrequency,
+		AreaNormalize: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(mel.Data[1][0]-0.02) > 0.005 {
+		t.Errorf("expected a weight of 0.02, found %g", mel.Data[1][0])
+	}
+}
+
+func TestMelFilterbank(t *testing.T) {
+	// the weights of librosa.filters.mel(sr=16000, n_fft=64, n_mels=6, htk=..., norm=...), computed with the formulas
+	// of librosa 0.10, excluding the Nyquist bin
+	tests := []struct {
+		scale         MelScale
+		areaNormalize bool
+		filters       []melFilter
+	}{
+		{MelScaleSlaney, false, []melFilter{
+			{1, []float64{0.5801663923, 0.8396672154, 0.2595008231}},
+			{2, []float64{0.1603327846, 0.7404991769, 0.7183417411, 0.2087495939}},
+			{4, []float64{0.2816582589, 0.7912504061, 0.8049615978, 0.4745893247, 0.1442170517}},
+			{6, []float64{
+				0.1950384022, 0.5254106753, 0.8557829483, 0.8806342231, 0.6687941105, 0.456953998, 0.2451138854,
+				0.03327377286,
+			}},
+			{9, []float64{
+				0.1193657769, 0.3312058895, 0.543046002, 0.7548861146, 0.9667262271, 0.8855003383, 0.7496649885,
+				0.6138296386, 0.4779942888, 0.3421589389, 0.2063235891, 0.07048823926,
+			}},
+			{14, []float64{
+				0.1144996617, 0.2503350115, 0.3861703614, 0.5220057112, 0.6578410611, 0.7936764109, 0.9295117607,
+				0.9580983624, 0.8709985113, 0.7838986601, 0.696798809, 0.6096989579, 0.5225991068, 0.4354992556,
+				0.3483994045, 0.2612995534, 0.1741997023, 0.08709985113,
+			}},
+		}},
+		{MelScaleSlaney, true, []melFilter{
+			{1, []float64{0.001346372171, 0.001948586796, 0.0006022146253}},
+			{2, []float64{0.0003479824455, 0.001607161724, 0.001559071754, 0.0004530651317}},
+			{4, []float64{0.000451624928, 0.001268730443, 0.001290715653, 0.0007609802405, 0.0002312448278}},
+			{6, []float64{
+				0.0002013967573, 0.0005425393411, 0.0008836819249, 0.0009093433644, 0.0006905971521, 0.0004718509399,
+				0.0002531047277, 3.435851545e-05,
+			}},
+			{9, []float64{
+				7.903451258e-05, 0.0002192981666, 0.0003595618207, 0.0004998254748, 0.0006400891288, 0.0005863078131,
+				0.0004963684608, 0.0004064291085, 0.0003164897562, 0.0002265504038, 0.0001366110515, 4.667169918e-05,
+			}},
+			{14, []float64{
+				4.861225426e-05, 0.0001062828401, 0.0001639534259, 0.0002216240117, 0.0002792945975, 0.0003369651833,
+				0.0003946357691, 0.0004067725661, 0.0003697932419, 0.0003328139177, 0.0002958345935, 0.0002588552693,
+				0.0002218759451, 0.0001848966209, 0.0001479172967, 0.0001109379726, 7.395864837e-05, 3.697932419e-05,
+			}},
+		}},
+		{MelScaleHTK, false, []melFilter{
+			{1, []float64{0.8241840683, 0.5476487693}},
+			{2, []float64{0.4523512307, 0.9809080049, 0.5797343176, 0.1785606303}},
+			{3, []float64{
+				0.01909199509, 0.4202656824, 0.8214393697, 0.8446880952, 0.5647986298, 0.2849091644, 0.00501969891,
+			}},
+			{6, []float64{
+				0.1553119048, 0.4352013702, 0.7150908356, 0.9949803011, 0.8082298155, 0.6129575049, 0.4176851943,
+				0.2224128837, 0.02714057312,
+			}},
+			{10, []float64{
+				0.1917701845, 0.3870424951, 0.5823148057, 0.7775871163, 0.9728594269, 0.8826984331, 0.7464615253,
+				0.6102246176, 0.4739877098, 0.337750802, 0.2015138943, 0.06527698653,
+			}},
+			{15, []float64{
+				0.1173015669, 0.2535384747, 0.3897753824, 0.5260122902, 0.662249198, 0.7984861057, 0.9347230135,
+				0.9504929285, 0.8554436356, 0.7603943428, 0.6653450499, 0.5702957571, 0.4752464642, 0.3801971714,
+				0.2851478785, 0.1900985857, 0.09504929285,
+			}},
+		}},
+		{MelScaleHTK, true, []melFilter{
+			{1, []float64{0.00223325125, 0.001483937079}},
+			{2, []float64{0.0008551518884, 0.001854367306, 0.001095964514, 0.0003375617217}},
+			{3, []float64{
+				2.518099631e-05, 0.0005543008233, 0.00108342065, 0.001114084081, 0.0007449295973, 0.0003757751132,
+				6.620629177e-06,
+			}},
+			{6, []float64{
+				0.0001429158688, 0.0004004662877, 0.0006580167066, 0.0009155671255, 0.000743721909, 0.0005640350268,
+				0.0003843481447, 0.0002046612625, 2.497438038e-05,
+			}},
+			{10, []float64{
+				0.0001231149821, 0.0002484783023, 0.0003738416224, 0.0004992049426, 0.0006245682627, 0.0005666855988,
+				0.0004792225528, 0.0003917595068, 0.0003042964608, 0.0002168334148, 0.0001293703688, 4.190732283e-05,
+			}},
+			{15, []float64{
+				5.253971908e-05, 0.0001135606334, 0.0001745815477, 0.000235602462, 0.0002966233763, 0.0003576442905,
+				0.0004186652048, 0.0004257285965, 0.0003831557368, 0.0003405828772, 0.0002980100175, 0.0002554371579,
+				0.0002128642982, 0.0001702914386, 0.0001277185789, 8.514571929e-05, 4.257285965e-05,
+			}},
+		}},
+	}
+	for _, test := range tests {
+		mel, err := identitySpectrogram(16000, 64).ToMel(MelOptions{
+			NumMels:       6,
+			Scale:         test.scale,
+			AreaNormalize: test.areaNormalize,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for m, filter := range test.filters {
+			for j := range mel.Data {
+				expected := 0.0
+				if (j >= filter.firstBin) && (j < filter.firstBin+len(filter.weights)) {
+					expected = filter.weights[j-filter.firstBin]
+				}
+				if math.Abs(mel.Data[j][m]-expected) > 1e-9*expected {
+					t.Errorf("scale %d, area normalize %v, band %d, bin %d: expected %.10g, found %.10g",
+						test.scale, test.areaNormalize, m, j, expected, mel.Data[j][m])
+				}
+			}
+		}
+	}
+}
+
+func TestMelDecibels(t *testing.T) {
+	weights, err := identitySpectrogram(16000, 64).ToMel(MelOptions{NumMels: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := identitySpectrogram(16000, 64)
+	spec.Scaling = ScalingAmplitude
+	floor := -200.0
+	spec.Floor = &floor
+	for j := range spec.Data {
+		for i := range spec.Data[j] {
+			spec.Data[j][i] = floor
+		}
+	}
+	// the first column is of a constant level, the others at the floor
+	for i := range spec.Data[0] {
+		spec.Data[0][i] = -20
+	}
+
+	mel, err := spec.ToMel(MelOptions{NumMels: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := range mel.Data[0] {
+		// the power of the bins, weighted by the filter
+		sum := 0.0
+		for j := range weights.Data {
+			sum += 0.01 * weights.Data[j][m]
+		}
+		if math.Abs(mel.Data[0][m]-10*math.Log10(sum)) > 1e-9 {
+			t.Errorf("band %d: expected %g dB, found %g dB", m, 10*math.Log10(sum), mel.Data[0][m])
+		}
+		for i := 1; i < len(mel.Data); i++ {
+			if mel.Data[i][m] < floor {
+				t.Fatalf("column %d, band %d: expected at least the floor, found %g", i, m, mel.Data[i][m])
+			}
+		}
+	}
+}
+
+func TestMelOfSilence(t *testing.T) {
+	overlap := uint(0)
+	options := SpectrogramOptions{
+		FftSamples:     256,
+		Overlap:        &overlap,
+		WindowFunction: window.Hann,
+	}
+	spec, err := GenerateSpectrogram(&memorySource{
+		info:   memoryInfo(1, 16000, 4096),
+		frames: sineFrames(16000, 4096, []float64{0}, []float64{0}),
+	}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 128 bands of a 256-point FFT at 16 kHz include bands between bins, as librosa warns of
+	_, err = spec.ToMel(MelOptions{NumMels: 128})
+	if err == nil {
+		t.Error("expected an error for bands without FFT bins")
+	}
+
+	mel, err := spec.ToMel(MelOptions{NumMels: 40, AreaNormalize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, column := range mel.Data {
+		for m, val := range column {
+			if val != DefaultFloor {
+				t.Fatalf("column %d, band %d: expected %g dB, found %g dB", i, m, DefaultFloor, val)
+			}
+		}
+	}
+}
@@ -3,26 +3,35 @@ package spectrogram
 import (
 	"errors"
 	"github.com/montanaflynn/stats"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
+	"sort"
 )
 
+// panelGap is the height of the gap between the panels of a multi-channel spectrogram.
+const panelGap = 2
+
 type RenderOptions struct {
 	MinFrequency         *uint
 	MaxFrequency         *uint
 	RelativeMinFrequency *float64
 	RelativeMaxFrequency *float64
-	RelativeMinDecibels  *float64
-	RelativeMaxDecibels  *float64
+	RelativeMinDecibels  *float64 // relative to the median, in the unit of the spectrogram
+	RelativeMaxDecibels  *float64 // relative to the median, in the unit of the spectrogram
 	ColorMap             []color.Color
 }
 
 type RenderInfo struct {
 	MinFrequency uint
 	MaxFrequency uint
-	MinDb        float64
-	MaxDb        float64
+	MinDb        float64 // the value of the first color, in Unit
+	MaxDb        float64 // the value of the last color, in Unit
+	Unit         string
 }
 
 func (spectrogram *Spectrogram) ToImage(options RenderOptions) (image.Image, *RenderInfo, error) {
@@ -74,15 +83,40 @@ func (spectrogram *Spectrogram) ToImage(options RenderOptions) (image.Image, *Re
 		return nil, nil, errors.New("minFrequency must be less than maxFrequency")
 	}
 
-	minIndexRatio := minFreq / fsOver2
-	maxIndexRatio := maxFreq / fsOver2
-	minIndex := int(math.Floor(minIndexRatio * float64(spectrogram.FftSamples/2)))
-	maxIndex := int(math.Min(math.Ceil(maxIndexRatio*float64(spectrogram.FftSamples/2)), float64((spectrogram.FftSamples/2)-1)))
+	var minIndex, maxIndex int
+	if spectrogram.MelFrequencies != nil {
+		// the bands centered within the range, one row each
+		bands := spectrogram.MelFrequencies
+		minIndex = sort.SearchFloat64s(bands, minFreq)
+		maxIndex = sort.Search(len(bands), func(i int) bool {
+			return bands[i] > maxFreq
+		}) - 1
+		if minIndex > maxIndex {
+			return nil, nil, errors.New("no mel bands between minFrequency and maxFrequency")
+		}
+	} else {
+		minIndexRatio := minFreq / fsOver2
+		maxIndexRatio := maxFreq / fsOver2
+		minIndex = int(math.Floor(minIndexRatio * float64(spectrogram.FftSamples/2)))
+		maxIndex = int(math.Min(math.Ceil(maxIndexRatio*float64(spectrogram.FftSamples/2)), float64((spectrogram.FftSamples/2)-1)))
+	}
 
+	// the panels share the dB scale
+	panels := [][][]float64{spectrogram.Data}
+	if spectrogram.ChannelData != nil {
+		panels = spectrogram.ChannelData
+	}
+	// non-finite values, e.g. -Inf for silence without a floor, are ignored
 	statsValues := make([]float64, 0)
-	for i := 0; i < len(spectrogram.Data); i++ {
-		specColumn := spectrogram.Data[i]
-		statsValues = append(statsValues, specColumn[minIndex:maxIndex+1]...)
+	for _, panel := range panels {
+		for i := 0; i < len(panel); i++ {
+			specColumn := panel[i]
+			for _, val := range specColumn[minIndex : maxIndex+1] {
+				if !math.IsInf(val, 0) && !math.IsNaN(val) {
+					statsValues = append(statsValues, val)
+				}
+			}
+		}
 	}
 
 	var median float64
@@ -118,22 +152,33 @@ func (spectrogram *Spectrogram) ToImage(options RenderOptions) (image.Image, *Re
 
 	dbRange := maxDb - minDb
 
-	imageHeight := maxIndex - minIndex + 1
+	panelHeight := maxIndex - minIndex + 1
+	imageHeight := len(panels)*(panelHeight+panelGap) - panelGap
 	img := image.NewNRGBA(image.Rect(0, 0, len(spectrogram.Data), imageHeight))
-	for x := 0; x < len(spectrogram.Data); x++ {
-		specColumn := spectrogram.Data[x]
-		for y := minIndex; y <= maxIndex; y++ {
-			spec := math.Min(math.Max(specColumn[y], minDb), maxDb)
-			normalizedSpec := (spec - minDb) / dbRange
-			colorIndex := int(math.Round(normalizedSpec * float64(len(options.ColorMap)-1)))
-			if colorIndex < 0 {
-				colorIndex = 0
-			}
-			if colorIndex >= len(options.ColorMap) {
-				colorIndex = len(options.ColorMap) - 1
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	for k, panel := range panels {
+		panelTop := k * (panelHeight + panelGap)
+		for x := 0; x < len(panel); x++ {
+			specColumn := panel[x]
+			for y := minIndex; y <= maxIndex; y++ {
+				spec := math.Min(math.Max(specColumn[y], minDb), maxDb)
+				normalizedSpec := 0.0
+				if (dbRange > 0) && !math.IsNaN(spec) {
+					normalizedSpec = (spec - minDb) / dbRange
+				}
+				colorIndex := int(math.Round(normalizedSpec * float64(len(options.ColorMap)-1)))
+				if colorIndex < 0 {
+					colorIndex = 0
+				}
+				if colorIndex >= len(options.ColorMap) {
+					colorIndex = len(options.ColorMap) - 1
+				}
+				c := options.ColorMap[colorIndex]
+				img.Set(x, panelTop+panelHeight-(y-minIndex)-1, c)
 			}
-			c := options.ColorMap[colorIndex]
-			img.Set(x, imageHeight-(y-minIndex)-1, c)
+		}
+		if spectrogram.ChannelData != nil {
+			drawLabel(img, spectrogram.ChannelNames[k], image.Pt(0, panelTop))
 		}
 	}
 
@@ -142,5 +187,21 @@ func (spectrogram *Spectrogram) ToImage(options RenderOptions) (image.Image, *Re
 		MaxFrequency: uint(maxFreq),
 		MinDb:        minDb,
 		MaxDb:        maxDb,
+		Unit:         spectrogram.Unit,
 	}, nil
 }
+
+// drawLabel draws text in white on a black background, with its top-left corner at pt.
+func drawLabel(img draw.Image, text string, pt image.Point) {
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.White),
+		Face: face,
+	}
+	width := drawer.MeasureString(text).Ceil()
+	background := image.Rect(pt.X, pt.Y, pt.X+width+4, pt.Y+face.Height+2).Intersect(img.Bounds())
+	draw.Draw(img, background, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	drawer.Dot = fixed.P(pt.X+2, pt.Y+1+face.Ascent)
+	drawer.DrawString(text)
+}
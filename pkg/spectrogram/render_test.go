@@ -0,0 +1,163 @@
+package spectrogram
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/mjibson/go-dsp/window"
+)
+
+// constantColumns returns numColumns columns of numBins bins of the value.
+func constantColumns(numColumns int, numBins int, value float64) [][]float64 {
+	columns := make([][]float64, numColumns)
+	for i := range columns {
+		columns[i] = make([]float64, numBins)
+		for j := range columns[i] {
+			columns[i][j] = value
+		}
+	}
+	return columns
+}
+
+func TestChannelModeAll(t *testing.T) {
+	source := &memorySource{
+		info:   memoryInfo(2, 8000, 4000),
+		frames: sineFrames(8000, 4000, []float64{0.5, 0.25}, []float64{440, 1250}),
+	}
+	source.info.ChannelNames = []string{"FL", ""}
+	options := SpectrogramOptions{
+		ChannelMode:    ChannelModeAll,
+		FftSamples:     512,
+		WindowFunction: window.Hann,
+		Scaling:        ScalingAmplitude,
+		Workers:        1,
+	}
+	spectrogram, err := GenerateSpectrogram(source, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spectrogram.ChannelData) != 2 {
+		t.Fatalf("expected 2 panels, found %d", len(spectrogram.ChannelData))
+	}
+	// unnamed channels are numbered
+	if (spectrogram.ChannelNames[0] != "FL") || (spectrogram.ChannelNames[1] != "channel 1") {
+		t.Errorf("expected the channel names FL and channel 1, found %q", spectrogram.ChannelNames)
+	}
+	compareSpectrograms(t, "data", spectrogram.Data, spectrogram.ChannelData[0])
+
+	// each panel is the spectrogram of its channel
+	options.ChannelMode = ChannelModeSingle
+	for channel := range spectrogram.ChannelData {
+		options.Channel = uint(channel)
+		expected, err := GenerateSpectrogram(source, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSpectrograms(t, spectrogram.ChannelNames[channel], spectrogram.ChannelData[channel], expected.Data)
+	}
+}
+
+func TestToImagePanels(t *testing.T) {
+	// a quiet and a loud panel of 40 columns of 32 bins
+	spectrogram := &Spectrogram{
+		SampleRate:   8000,
+		NumChannels:  2,
+		FftSamples:   64,
+		Unit:         "dBFS",
+		ChannelData:  [][][]float64{constantColumns(40, 32, -60), constantColumns(40, 32, -20)},
+		ChannelNames: []string{"FL", "FR"},
+	}
+	spectrogram.Data = spectrogram.ChannelData[0]
+	colorMap := []color.Color{color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255}, color.NRGBA{B: 255, A: 255}}
+	img, info, err := spectrogram.ToImage(RenderOptions{ColorMap: colorMap})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the panels share the scale
+	if (info.MinDb != -60) || (info.MaxDb != -20) || (info.Unit != "dBFS") {
+		t.Errorf("unexpected render info: %+v", info)
+	}
+	bounds := img.Bounds()
+	if (bounds.Dx() != 40) || (bounds.Dy() != 2*32+panelGap) {
+		t.Fatalf("expected a 40x%d image, found %dx%d", 2*32+panelGap, bounds.Dx(), bounds.Dy())
+	}
+	rgba := func(x int, y int) color.NRGBA {
+		return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	}
+	// to the right of the labels
+	for x := 30; x < 40; x++ {
+		for y := 0; y < 32; y++ {
+			if rgba(x, y) != colorMap[0] {
+				t.Fatalf("pixel (%d, %d): expected the first color, found %v", x, y, rgba(x, y))
+			}
+			if rgba(x, 32+panelGap+y) != colorMap[2] {
+				t.Fatalf("pixel (%d, %d): expected the last color, found %v", x, 32+panelGap+y, rgba(x, 32+panelGap+y))
+			}
+		}
+		for y := 32; y < 32+panelGap; y++ {
+			if rgba(x, y) != (color.NRGBA{A: 255}) {
+				t.Fatalf("pixel (%d, %d): expected the black gap, found %v", x, y, rgba(x, y))
+			}
+		}
+	}
+	// each panel is labelled in white at its top-left corner
+	for _, top := range []int{0, 32 + panelGap} {
+		white := 0
+		for x := 0; x < 20; x++ {
+			for y := top; y < top+15; y++ {
+				if rgba(x, y) == (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+					white++
+				}
+			}
+		}
+		if white == 0 {
+			t.Errorf("expected a label at row %d", top)
+		}
+	}
+}
+
+func TestToImageNonFinite(t *testing.T) {
+	// silence without a floor is -Inf
+	columns := constantColumns(4, 32, math.Inf(-1))
+	columns[1][3] = -80
+	columns[2][5] = -10
+	columns[3][7] = math.NaN()
+	columns[3][8] = math.Inf(1)
+	spectrogram := &Spectrogram{
+		SampleRate:  8000,
+		NumChannels: 1,
+		FftSamples:  64,
+		Unit:        "dBFS",
+		Data:        columns,
+	}
+	colorMap := []color.Color{color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}}
+	relative := 0.0
+	for _, test := range []struct {
+		options RenderOptions
+		minDb   float64
+	}{
+		{RenderOptions{ColorMap: colorMap}, -80},
+		// the median of the finite values only
+		{RenderOptions{ColorMap: colorMap, RelativeMinDecibels: &relative}, -45},
+	} {
+		img, info, err := spectrogram.ToImage(test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (info.MinDb != test.minDb) || (info.MaxDb != -10) {
+			t.Errorf("expected a range of %g to -10 dB, found %g to %g", test.minDb, info.MinDb, info.MaxDb)
+		}
+		// -Inf and NaN have the first color, +Inf the last
+		for _, pixel := range []struct {
+			x, bin   int
+			expected color.Color
+		}{{0, 0, colorMap[0]}, {3, 7, colorMap[0]}, {3, 8, colorMap[1]}, {2, 5, colorMap[1]}} {
+			found := color.NRGBAModel.Convert(img.At(pixel.x, 31-pixel.bin))
+			if found != pixel.expected {
+				t.Errorf("column %d, bin %d: expected %v, found %v", pixel.x, pixel.bin, pixel.expected, found)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package spectrogram
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scaling selects the quantity of the bins of a spectrogram. The window is corrected for except by ScalingDecibels.
+type Scaling int
+
+const (
+	ScalingDecibels  Scaling = iota // 20 log10(|X| 2 / N), the magnitude in dB, uncorrected for the window
+	ScalingMagnitude                // |X| 2 / sum(w), the linear amplitude of a sinusoid
+	ScalingPower                    // (|X| 2 / sum(w))² / 2, the linear power of a sinusoid
+	ScalingPSD                      // 10 log10(|X|² 2 / (fs sum(w²))), the power spectral density in dB re 1/Hz
+	ScalingAmplitude                // 20 log10(|X| 2 / sum(w)), the amplitude of a sinusoid in dB
+)
+
+var scalingNames = map[string]Scaling{
+	"db":        ScalingDecibels,
+	"magnitude": ScalingMagnitude,
+	"power":     ScalingPower,
+	"psd":       ScalingPSD,
+	"amplitude": ScalingAmplitude,
+}
+
+// ParseScaling parses a scaling name: db, magnitude, power, psd or amplitude.
+func ParseScaling(s string) (Scaling, error) {
+	scaling, ok := scalingNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown scaling: %s", s)
+	}
+	return scaling, nil
+}
+
+// Reference selects the level of 0 dB of the dB scalings.
+type Reference int
+
+const (
+	ReferenceFullScale Reference = iota // dBFS, where a full-scale sinusoid is 0 dB in amplitude and in power
+	ReferenceUnity                      // dB re 1, where a full-scale sinusoid has a power of -3.01 dB
+	ReferenceSPL                        // dB SPL, where a full-scale sinusoid is at the calibration level
+)
+
+var referenceNames = map[string]Reference{
+	"dbfs": ReferenceFullScale,
+	"re1":  ReferenceUnity,
+	"spl":  ReferenceSPL,
+}
+
+// ParseReference parses a reference name: dbfs, re1 or spl.
+func ParseReference(s string) (Reference, error) {
+	reference, ok := referenceNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown reference: %s", s)
+	}
+	return reference, nil
+}
+
+// DefaultFloor is the floor of the dB scalings when none is specified, in dB.
+const DefaultFloor = -200.0
+
+// Unit returns the unit of the bins, relative to digital full scale for the linear scalings, which have no reference.
+func (scaling Scaling) Unit(reference Reference) string {
+	switch scaling {
+	case ScalingMagnitude:
+		return "FS"
+	case ScalingPower:
+		return "FS²"
+	}
+
+	unit := "dBFS"
+	if reference == ReferenceUnity {
+		unit = "dB"
+	} else if reference == ReferenceSPL {
+		unit = "dB SPL"
+	}
+	if scaling == ScalingPSD {
+		unit += "/Hz"
+	}
+	return unit
+}
+
+func (scaling Scaling) isDecibels() bool {
+	return (scaling != ScalingMagnitude) && (scaling != ScalingPower)
+}
+
+// binScaler converts the power of a bin, |X|², summed over the signals of a panel, to the value of the bin.
+type binScaler struct {
+	scaling Scaling
+	factor  float64
+	offset  float64 // added to the dB scalings, for the reference
+	floor   float64 // the lowest value of the dB scalings, so that silence is not -Inf
+}
+
+func newBinScaler(options SpectrogramOptions, window []float64, sampleRate int) (binScaler, error) {
+	sum := 0.0
+	sumOfSquares := 0.0
+	for _, w := range window {
+		sum += w
+		sumOfSquares += w * w
+	}
+
+	scaler := binScaler{
+		scaling: options.Scaling,
+		floor:   DefaultFloor,
+	}
+	if options.Floor != nil {
+		scaler.floor = *options.Floor
+	}
+
+	// for ScalingPSD, the power is divided by the equivalent noise bandwidth, fs sum(w²) / sum(w)²
+	switch options.Scaling {
+	case ScalingDecibels:
+		scaler.factor = 2 / float64(len(window))
+	case ScalingMagnitude, ScalingAmplitude:
+		scaler.factor = 2 / sum
+	case ScalingPower:
+		scaler.factor = 2 / (sum * sum)
+	case ScalingPSD:
+		scaler.factor = 2 / (float64(sampleRate) * sumOfSquares)
+		if options.Reference != ReferenceUnity {
+			// relative to the power of a full-scale sinusoid, 1/2
+			scaler.offset = 10 * math.Log10(2)
+		}
+	default:
+		return binScaler{}, fmt.Errorf("unknown scaling: %d", options.Scaling)
+	}
+
+	switch options.Reference {
+	case ReferenceFullScale, ReferenceUnity:
+	case ReferenceSPL:
+		scaler.offset += options.Calibration
+	default:
+		return binScaler{}, fmt.Errorf("unknown reference: %d", options.Reference)
+	}
+	return scaler, nil
+}
+
+func (s binScaler) scale(power float64) float64 {
+	switch s.scaling {
+	case ScalingMagnitude:
+		return math.Sqrt(power) * s.factor
+	case ScalingPower:
+		return power * s.factor
+	case ScalingPSD:
+		return math.Max(10*math.Log10(power*s.factor)+s.offset, s.floor)
+	}
+	return math.Max(20*math.Log10(math.Sqrt(power)*s.factor)+s.offset, s.floor)
+}
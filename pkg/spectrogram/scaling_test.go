@@ -0,0 +1,187 @@
+package spectrogram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mjibson/go-dsp/window"
+)
+
+func TestScaling(t *testing.T) {
+	// a sinusoid at the center of bin 125, in a single column
+	const amplitude = 0.25
+	source := &memorySource{
+		info:   memoryInfo(1, 8000, 1000),
+		frames: sineFrames(8000, 1000, []float64{amplitude}, []float64{1000}),
+	}
+	windows := []struct {
+		name           string
+		windowFunction WindowFunction
+	}{
+		{"rectangular", window.Rectangular},
+		{"Hann", window.Hann},
+		{"Blackman", window.Blackman},
+	}
+	for _, w := range windows {
+		coefficients := w.windowFunction(1000)
+		sum := 0.0
+		sumOfSquares := 0.0
+		for _, c := range coefficients {
+			sum += c
+			sumOfSquares += c * c
+		}
+		// the equivalent noise bandwidth of the window
+		bandwidth := 8000 * sumOfSquares / (sum * sum)
+		power := amplitude * amplitude / 2
+
+		tests := []struct {
+			scaling     Scaling
+			reference   Reference
+			calibration float64
+			expected    float64
+		}{
+			{ScalingMagnitude, ReferenceFullScale, 0, amplitude},
+			{ScalingPower, ReferenceFullScale, 0, power},
+			{ScalingAmplitude, ReferenceFullScale, 0, 20 * math.Log10(amplitude)},
+			{ScalingAmplitude, ReferenceUnity, 0, 20 * math.Log10(amplitude)},
+			{ScalingAmplitude, ReferenceSPL, 94, 94 + 20*math.Log10(amplitude)},
+			// uncorrected for the coherent gain of the window
+			{ScalingDecibels, ReferenceFullScale, 0, 20 * math.Log10(amplitude*sum/1000)},
+			{ScalingPSD, ReferenceUnity, 0, 10 * math.Log10(power/bandwidth)},
+			{ScalingPSD, ReferenceFullScale, 0, 10 * math.Log10(2*power/bandwidth)},
+			{ScalingPSD, ReferenceSPL, 94, 94 + 10*math.Log10(2*power/bandwidth)},
+		}
+		for _, test := range tests {
+			spectrogram, err := GenerateSpectrogram(source, SpectrogramOptions{
+				FftSamples:     1000,
+				WindowFunction: w.windowFunction,
+				Scaling:        test.scaling,
+				Reference:      test.reference,
+				Calibration:    test.calibration,
+				Workers:        1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(spectrogram.Data) != 1 {
+				t.Fatalf("expected 1 column, found %d", len(spectrogram.Data))
+			}
+			found := spectrogram.Data[0][125]
+			if math.Abs(found-test.expected) > 1e-6*math.Max(math.Abs(test.expected), 1) {
+				t.Errorf("%s window, scaling %d, reference %d: expected %g, found %g", w.name, test.scaling,
+					test.reference, test.expected, found)
+			}
+		}
+	}
+
+	// the power spectral density integrates to the power of the signal
+	spectrogram, err := GenerateSpectrogram(source, SpectrogramOptions{
+		FftSamples:     1000,
+		WindowFunction: window.Hann,
+		Scaling:        ScalingPSD,
+		Reference:      ReferenceUnity,
+		Workers:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0.0
+	for _, v := range spectrogram.Data[0] {
+		total += math.Pow(10, v/10) * 8000 / 1000
+	}
+	if math.Abs(total-amplitude*amplitude/2) > 1e-3*amplitude*amplitude/2 {
+		t.Errorf("expected a total power of %g, found %g", amplitude*amplitude/2, total)
+	}
+}
+
+func TestScalingUnit(t *testing.T) {
+	tests := []struct {
+		scaling   Scaling
+		reference Reference
+		expected  string
+	}{
+		{ScalingMagnitude, ReferenceSPL, "FS"},
+		{ScalingPower, ReferenceUnity, "FS²"},
+		{ScalingDecibels, ReferenceFullScale, "dBFS"},
+		{ScalingAmplitude, ReferenceUnity, "dB"},
+		{ScalingAmplitude, ReferenceSPL, "dB SPL"},
+		{ScalingPSD, ReferenceFullScale, "dBFS/Hz"},
+		{ScalingPSD, ReferenceSPL, "dB SPL/Hz"},
+	}
+	for _, test := range tests {
+		found := test.scaling.Unit(test.reference)
+		if found != test.expected {
+			t.Errorf("scaling %d, reference %d: expected %q, found %q", test.scaling, test.reference, test.expected,
+				found)
+		}
+	}
+}
+
+func TestFloor(t *testing.T) {
+	// silence, followed by a sinusoid
+	source := &memorySource{
+		info:   memoryInfo(1, 8000, 2000),
+		frames: sineFrames(8000, 2000, []float64{0.5}, []float64{1000}),
+	}
+	for i := 0; i < 1000; i++ {
+		source.frames[i][0] = 0
+	}
+	floor := -120.0
+	tests := []struct {
+		scaling  Scaling
+		floor    *float64
+		expected float64
+	}{
+		{ScalingDecibels, nil, DefaultFloor},
+		{ScalingAmplitude, nil, DefaultFloor},
+		{ScalingPSD, nil, DefaultFloor},
+		{ScalingAmplitude, &floor, floor},
+		{ScalingPSD, &floor, floor},
+		// the linear scalings have no floor
+		{ScalingMagnitude, &floor, 0},
+		{ScalingPower, &floor, 0},
+	}
+	for _, test := range tests {
+		spectrogram, err := GenerateSpectrogram(source, SpectrogramOptions{
+			FftSamples:     1000,
+			WindowFunction: window.Hann,
+			Scaling:        test.scaling,
+			Floor:          test.floor,
+			Workers:        1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, v := range spectrogram.Data[0] {
+			if v != test.expected {
+				t.Errorf("scaling %d, bin %d of silence: expected %g, found %g", test.scaling, j, test.expected, v)
+				break
+			}
+		}
+		// the floor does not affect the sinusoid
+		if spectrogram.Data[1][125] <= test.expected {
+			t.Errorf("scaling %d: expected the sinusoid above %g, found %g", test.scaling, test.expected,
+				spectrogram.Data[1][125])
+		}
+		if !test.scaling.isDecibels() {
+			if spectrogram.Floor != nil {
+				t.Errorf("scaling %d: expected no floor, found %g", test.scaling, *spectrogram.Floor)
+			}
+		} else if (spectrogram.Floor == nil) || (*spectrogram.Floor != test.expected) {
+			t.Errorf("scaling %d: expected the floor %g, found %v", test.scaling, test.expected, spectrogram.Floor)
+		}
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	for name, expected := range referenceNames {
+		found, err := ParseReference(name)
+		if (err != nil) || (found != expected) {
+			t.Errorf("%q: expected %d, found %d (%v)", name, expected, found, err)
+		}
+	}
+	_, err := ParseReference("dbu")
+	if err == nil {
+		t.Errorf("expected an error for an unknown reference")
+	}
+}
@@ -2,41 +2,97 @@ package spectrogram
 
 import (
 	"errors"
-	"github.com/mjibson/go-dsp/fft"
-	"github.com/mjibson/go-dsp/window"
+	"fmt"
 	"github.com/ngyewch/go-spectrogram/pkg/audio"
-	"math"
+	"io"
+	"runtime"
+	"sync"
 )
 
 type WindowFunction func(int) []float64
 
 type SpectrogramOptions struct {
 	Channel        uint
+	ChannelMode    ChannelMode
+	ChannelWeights []float64 // the weight of each channel, for ChannelModeWeighted
 	FftSamples     uint
+	WindowSamples  uint // window length, zero-padded to FftSamples; 0 for FftSamples
 	Overlap        *uint
 	Segments       *uint
 	WindowFunction WindowFunction
+	Scaling        Scaling
+	Reference      Reference // the reference of the dB scalings
+	Calibration    float64   // for ReferenceSPL, the level of a full-scale sinusoid in dB SPL
+	Floor          *float64  // the lowest value of the dB scalings, nil for DefaultFloor
+	Start          *Time     // offset of the first frame, nil for the start of the audio
+	Duration       *Time     // length of the audio to process, nil for the rest of the audio
+	Workers        uint      // number of goroutines computing columns; 0 for the number of CPUs
 }
 
 type Spectrogram struct {
-	SampleRate  uint
-	NumChannels uint
-	FftSamples  uint
-	Data        [][]float64
+	SampleRate     uint
+	NumChannels    uint
+	FftSamples     uint
+	WindowSamples  uint
+	Scaling        Scaling
+	Unit           string        // the unit of the bins, from Scaling.Unit
+	Floor          *float64      // the lowest value of the dB scalings, nil for none
+	Data           [][]float64   // columns of bins; for ChannelModeAll, those of the first channel
+	ChannelData    [][][]float64 // for ChannelModeAll, the columns of each channel
+	ChannelNames   []string      // for ChannelModeAll, the name of each channel
+	MelFrequencies []float64     // for a mel spectrogram, the center frequency of each band; nil otherwise
 }
 
 func GenerateSpectrogram(audioFile audio.Source, options SpectrogramOptions) (*Spectrogram, error) {
-	info := audioFile.Info()
-	frames := audioFile.Frames()
+	return GenerateSpectrogramFromStream(audio.NewSourceStream(audioFile), options)
+}
 
-	channel := int(options.Channel)
-	if channel < 0 || channel >= info.NumChannels {
-		return nil, errors.New("invalid channel number")
+// GenerateSpectrogramFromStream generates a spectrogram while reading the stream, holding only a few windows of frames
+// per worker in memory at a time. The columns are computed concurrently by Workers goroutines, with the same result
+// for any number of workers. Streams that cannot seek must not have been read from if a start is specified.
+func GenerateSpectrogramFromStream(stream audio.StreamSource, options SpectrogramOptions) (*Spectrogram, error) {
+	info := stream.Info()
+
+	weights, err := channelWeights(options, info.NumChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	numFrames := info.NumFrames
+	start := int64(0)
+	if options.Start != nil {
+		start = options.Start.Frames(info.SampleRate)
+		if start < 0 {
+			return nil, errors.New("start must not be negative")
+		}
+		if (info.NumFrames > 0) && (start >= info.NumFrames) {
+			return nil, fmt.Errorf("start %s is beyond the end of the audio", options.Start)
+		}
+		if numFrames > 0 {
+			numFrames -= start
+		}
+	}
+	remaining := int64(-1) // frames left to read, -1 until the end of the stream
+	if options.Duration != nil {
+		remaining = options.Duration.Frames(info.SampleRate)
+		if remaining <= 0 {
+			return nil, errors.New("duration must be greater than 0")
+		}
+		if (numFrames <= 0) || (remaining < numFrames) {
+			numFrames = remaining
+		}
 	}
 
 	fftSamples := options.FftSamples
-	if !IsPowerOfTwo(fftSamples) {
-		return nil, errors.New("fftSamples must be a power of 2")
+	if fftSamples < 2 {
+		return nil, errors.New("fftSamples must be at least 2")
+	}
+	windowSamples := fftSamples
+	if options.WindowSamples != 0 {
+		windowSamples = options.WindowSamples
+		if windowSamples > fftSamples {
+			return nil, errors.New("windowSamples must not be greater than fftSamples")
+		}
 	}
 
 	overlap := 0
@@ -45,51 +101,191 @@ func GenerateSpectrogram(audioFile audio.Source, options SpectrogramOptions) (*S
 		return nil, errors.New("cannot specify both Segments and Overlap")
 	} else if (options.Segments == nil) && (options.Overlap == nil) {
 		overlap = 0
-		hop = int(fftSamples)
+		hop = int(windowSamples)
 	} else if (options.Segments == nil) && (options.Overlap != nil) {
-		if *options.Overlap >= fftSamples {
-			return nil, errors.New("overlap must be less than fftSamples")
+		if *options.Overlap >= windowSamples {
+			return nil, errors.New("overlap must be less than windowSamples")
 		}
 		overlap = int(*options.Overlap)
-		hop = int(fftSamples) - overlap
+		hop = int(windowSamples) - overlap
 	} else if (options.Segments != nil) && (options.Overlap == nil) {
 		if *options.Segments <= 1 {
 			return nil, errors.New("segments must be greater than 1")
 		}
-		hop = (len(frames) - int(fftSamples)) / int(*options.Segments-1)
+		if numFrames <= 0 {
+			return nil, errors.New("segments requires the number of frames to be known")
+		}
+		hop = int(numFrames-int64(windowSamples)) / int(*options.Segments-1)
 		if hop < 1 {
 			hop = 1
-		} else if hop > int(fftSamples) {
-			hop = int(fftSamples)
-		}
-		overlap = int(fftSamples) - hop
-	}
-
-	bSi := 2 / float64(fftSamples)
-	buffer := make([]float64, int(fftSamples))
-	specColumns := make([][]float64, 0)
-	for i := 0; (i + int(fftSamples)) <= len(frames); i += hop {
-		start := i
-		end := start + int(fftSamples)
-		currentFrame := frames[start:end]
-		for j := 0; j < int(fftSamples); j++ {
-			buffer[j] = currentFrame[j][channel]
-		}
-		window.Apply(buffer, options.WindowFunction)
-		fftResult := fft.FFTReal(buffer)
-		specColumn := make([]float64, len(buffer)/2)
-		for j := 0; j < len(buffer)/2; j++ {
-			val := fftResult[j]
-			mag := math.Sqrt((real(val)*real(val))+(imag(val)*imag(val))) * bSi
-			specColumn[j] = 20 * math.Log10(mag)
-		}
-		specColumns = append(specColumns, specColumn)
-	}
-
-	return &Spectrogram{
-		SampleRate:  uint(info.SampleRate),
-		NumChannels: uint(info.NumChannels),
-		FftSamples:  fftSamples,
-		Data:        specColumns,
-	}, nil
+		} else if hop > int(windowSamples) {
+			hop = int(windowSamples)
+		}
+		overlap = int(windowSamples) - hop
+	}
+
+	if start > 0 {
+		err := audio.Seek(stream, start)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	numPanels := 1
+	if options.ChannelMode == ChannelModeAll {
+		numPanels = len(weights)
+	}
+
+	workers := int(options.Workers)
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	windowCoefficients := options.WindowFunction(int(windowSamples))
+	scaler, err := newBinScaler(options, windowCoefficients, info.SampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// the workers return the sample buffers of their jobs to free, which bounds the windows held in memory
+	free := make(chan [][]float64, 2*workers)
+	for w := 0; w < cap(free); w++ {
+		jobSamples := make([][]float64, len(weights))
+		for i := range jobSamples {
+			jobSamples[i] = make([]float64, int(windowSamples))
+		}
+		free <- jobSamples
+	}
+	jobs := make(chan *columnJob, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := newColumnWorker(int(fftSamples), windowCoefficients, numPanels, scaler)
+			for job := range jobs {
+				job.columns = worker.compute(job.samples)
+				free <- job.samples
+				job.samples = nil
+			}
+		}()
+	}
+
+	samples := make([][]float64, len(weights))
+	for i := range samples {
+		samples[i] = make([]float64, int(windowSamples))
+	}
+	dst := make([][]float64, len(weights))
+	readBuffer := make([]float64, int(windowSamples)*info.NumChannels)
+	filled := 0
+	// in the order of the columns, whichever worker completes them first
+	columnJobs := make([]*columnJob, 0)
+	for {
+		numFrames := int(windowSamples) - filled
+		if (remaining >= 0) && (int64(numFrames) > remaining) {
+			numFrames = int(remaining)
+		}
+		for i := range dst {
+			dst[i] = samples[i][filled : filled+numFrames]
+		}
+		n, err := readSignals(stream, weights, dst, readBuffer)
+		if err != nil {
+			close(jobs)
+			wg.Wait()
+			return nil, err
+		}
+		filled += n
+		if remaining >= 0 {
+			remaining -= int64(n)
+		}
+		if filled < int(windowSamples) {
+			break
+		}
+
+		job := &columnJob{
+			samples: <-free,
+		}
+		for i := range samples {
+			copy(job.samples[i], samples[i])
+			copy(samples[i], samples[i][hop:])
+		}
+		columnJobs = append(columnJobs, job)
+		jobs <- job
+
+		filled -= hop
+	}
+	close(jobs)
+	wg.Wait()
+
+	specColumns := make([][][]float64, numPanels)
+	for k := range specColumns {
+		specColumns[k] = make([][]float64, len(columnJobs))
+		for c, job := range columnJobs {
+			specColumns[k][c] = job.columns[k]
+		}
+	}
+
+	spectrogram := &Spectrogram{
+		SampleRate:    uint(info.SampleRate),
+		NumChannels:   uint(info.NumChannels),
+		FftSamples:    fftSamples,
+		WindowSamples: windowSamples,
+		Scaling:       options.Scaling,
+		Unit:          options.Scaling.Unit(options.Reference),
+		Data:          specColumns[0],
+	}
+	if options.Scaling.isDecibels() {
+		spectrogram.Floor = &scaler.floor
+	}
+	if options.ChannelMode == ChannelModeAll {
+		spectrogram.ChannelData = specColumns
+		spectrogram.ChannelNames = make([]string, info.NumChannels)
+		for j := range spectrogram.ChannelNames {
+			spectrogram.ChannelNames[j] = fmt.Sprintf("channel %d", j)
+			if (j < len(info.ChannelNames)) && (info.ChannelNames[j] != "") {
+				spectrogram.ChannelNames[j] = info.ChannelNames[j]
+			}
+		}
+	}
+	return spectrogram, nil
+}
+
+// readChannel reads len(dst) frames from the stream, storing the samples of the specified channel in dst. Returns
+// fewer frames only at the end of the stream.
+func readChannel(stream audio.StreamSource, channel int, dst []float64, readBuffer []float64) (int, error) {
+	channelReader, ok := stream.(audio.ChannelReader)
+	if ok {
+		total := 0
+		for total < len(dst) {
+			n, err := channelReader.ReadChannel(channel, dst[total:])
+			total += n
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return total, err
+			}
+		}
+		return total, nil
+	}
+
+	numChannels := stream.Info().NumChannels
+	total := 0
+	for total < len(dst) {
+		maxFrames := len(dst) - total
+		if maxFrames > len(readBuffer)/numChannels {
+			maxFrames = len(readBuffer) / numChannels
+		}
+		n, err := stream.ReadFrames(readBuffer[:maxFrames*numChannels])
+		for i := 0; i < n; i++ {
+			dst[total+i] = readBuffer[i*numChannels+channel]
+		}
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
 }
@@ -0,0 +1,116 @@
+package spectrogram
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/mjibson/go-dsp/window"
+	"github.com/ngyewch/go-spectrogram/pkg/audio"
+)
+
+// memorySource is an audio.Source of frames held in memory.
+type memorySource struct {
+	info   audio.Info
+	frames [][]float64
+}
+
+func (src *memorySource) Info() audio.Info {
+	return src.info
+}
+
+func (src *memorySource) Frames() [][]float64 {
+	return src.frames
+}
+
+func memoryInfo(numChannels int, sampleRate int, numFrames int) audio.Info {
+	return audio.Info{
+		NumChannels: numChannels,
+		SampleRate:  sampleRate,
+		NumFrames:   int64(numFrames),
+	}
+}
+
+// sineFrames returns numFrames frames of sinusoids of the amplitudes and frequencies, one per channel.
+func sineFrames(sampleRate int, numFrames int, amplitudes []float64, frequencies []float64) [][]float64 {
+	frames := make([][]float64, numFrames)
+	for i := range frames {
+		frames[i] = make([]float64, len(amplitudes))
+		for j := range amplitudes {
+			frames[i][j] = amplitudes[j] * math.Sin(2*math.Pi*frequencies[j]*float64(i)/float64(sampleRate))
+		}
+	}
+	return frames
+}
+
+func TestZeroPaddedWindow(t *testing.T) {
+	source := &memorySource{
+		info:   memoryInfo(1, 8000, 3000),
+		frames: sineFrames(8000, 3000, []float64{0.5}, []float64{441.7}),
+	}
+	for i, frame := range source.frames {
+		frame[0] += 0.1 * math.Cos(2*math.Pi*2345*float64(i)/8000)
+	}
+	// a window of 400 samples every 300 samples, zero-padded to an FFT of 1000 samples
+	overlap := uint(100)
+	spectrogram, err := GenerateSpectrogram(source, SpectrogramOptions{
+		FftSamples:     1000,
+		WindowSamples:  400,
+		Overlap:        &overlap,
+		WindowFunction: window.Hann,
+		Scaling:        ScalingPower,
+		Workers:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (spectrogram.FftSamples != 1000) || (spectrogram.WindowSamples != 400) {
+		t.Errorf("expected 1000 FFT samples and 400 window samples, found %d and %d", spectrogram.FftSamples,
+			spectrogram.WindowSamples)
+	}
+
+	// the DFT of each window, evaluated directly
+	coefficients := window.Hann(400)
+	sum := 0.0
+	for _, w := range coefficients {
+		sum += w
+	}
+	expected := make([][]float64, (3000-400)/300+1)
+	for c := range expected {
+		expected[c] = make([]float64, 500)
+		for k := range expected[c] {
+			var x complex128
+			for n, w := range coefficients {
+				x += complex(source.frames[300*c+n][0]*w, 0) * cmplx.Exp(complex(0, -2*math.Pi*float64(k*n)/1000))
+			}
+			expected[c][k] = real(x*cmplx.Conj(x)) * 2 / (sum * sum)
+		}
+	}
+	compareSpectrograms(t, "zero-padded", spectrogram.Data, expected)
+	if math.Abs(spectrogram.Data[0][55]-0.125) > 0.01 {
+		t.Errorf("expected a power of 0.125 near 441.7 Hz, found %g", spectrogram.Data[0][55])
+	}
+}
+
+func TestWindowSamplesErrors(t *testing.T) {
+	source := &memorySource{
+		info:   memoryInfo(1, 8000, 3000),
+		frames: sineFrames(8000, 3000, []float64{0.5}, []float64{440}),
+	}
+	overlap := uint(400)
+	tests := []struct {
+		name    string
+		options SpectrogramOptions
+	}{
+		{"FFT too short", SpectrogramOptions{FftSamples: 1}},
+		{"window longer than the FFT", SpectrogramOptions{FftSamples: 1000, WindowSamples: 1001}},
+		{"overlap of the window", SpectrogramOptions{FftSamples: 1000, WindowSamples: 400, Overlap: &overlap}},
+	}
+	for _, test := range tests {
+		test.options.WindowFunction = window.Hann
+		_, err := GenerateSpectrogram(source, test.options)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package spectrogram
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Time is a position or length in the audio, in seconds or in samples.
+type Time struct {
+	seconds   float64
+	samples   int64
+	isSamples bool
+}
+
+func Seconds(seconds float64) Time {
+	return Time{seconds: seconds}
+}
+
+func Samples(samples int64) Time {
+	return Time{samples: samples, isSamples: true}
+}
+
+// ParseTime parses a time in seconds (e.g. 90 or 2.5s), in samples (e.g. 48000smp), or as [[hh:]mm:]ss[.mmm] (e.g.
+// 1:30 or 00:01:30.250).
+func ParseTime(s string) (Time, error) {
+	if strings.HasSuffix(s, "smp") {
+		samples, err := strconv.ParseInt(strings.TrimSuffix(s, "smp"), 10, 64)
+		if (err != nil) || (samples < 0) {
+			return Time{}, fmt.Errorf("invalid time: %s", s)
+		}
+		return Samples(samples), nil
+	}
+
+	parts := strings.Split(strings.TrimSuffix(s, "s"), ":")
+	if len(parts) > 3 {
+		return Time{}, fmt.Errorf("invalid time: %s", s)
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if (err != nil) || (seconds < 0) || math.IsInf(seconds, 0) || math.IsNaN(seconds) ||
+		((len(parts) > 1) && (seconds >= 60)) {
+		return Time{}, fmt.Errorf("invalid time: %s", s)
+	}
+	multiplier := 60.0
+	for i := len(parts) - 2; i >= 0; i-- {
+		value, err := strconv.ParseUint(parts[i], 10, 32)
+		if (err != nil) || ((i > 0) && (value >= 60)) {
+			return Time{}, fmt.Errorf("invalid time: %s", s)
+		}
+		seconds += float64(value) * multiplier
+		multiplier *= 60
+	}
+	return Seconds(seconds), nil
+}
+
+// Frames returns the number of frames at the sample rate, rounding seconds to the nearest frame.
+func (t Time) Frames(sampleRate int) int64 {
+	if t.isSamples {
+		return t.samples
+	}
+	return int64(math.Round(t.seconds * float64(sampleRate)))
+}
+
+func (t Time) String() string {
+	if t.isSamples {
+		return fmt.Sprintf("%dsmp", t.samples)
+	}
+	return fmt.Sprintf("%gs", t.seconds)
+}
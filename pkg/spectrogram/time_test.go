@@ -0,0 +1,78 @@
+package spectrogram
+
+import (
+	"testing"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Time
+	}{
+		{"90", Seconds(90)},
+		{"2.5s", Seconds(2.5)},
+		{"0", Seconds(0)},
+		{"48000smp", Samples(48000)},
+		{"0smp", Samples(0)},
+		{"1:30", Seconds(90)},
+		{"00:01:30.250", Seconds(90.25)},
+		{"2:00:00", Seconds(7200)},
+		{"100:00", Seconds(6000)},
+	}
+	for _, test := range tests {
+		found, err := ParseTime(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+		if found != test.expected {
+			t.Errorf("%q: expected %s, found %s", test.s, test.expected, found)
+		}
+	}
+}
+
+func TestParseTimeRejects(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"s",
+		"smp",
+		"-1",
+		"-48000smp",
+		"2.5smp",
+		"1:60",
+		"1:60:00",
+		"1::30",
+		"1:2:3:4",
+		"-1:30",
+		"1.5:30",
+		"inf",
+		"NaN",
+		"abc",
+		"1:30s5",
+	} {
+		found, err := ParseTime(s)
+		if err == nil {
+			t.Errorf("%q: expected an error, found %s", s, found)
+		}
+	}
+}
+
+func TestTimeFrames(t *testing.T) {
+	tests := []struct {
+		time       Time
+		sampleRate int
+		expected   int64
+	}{
+		{Seconds(2.5), 48000, 120000},
+		{Seconds(1.0 / 3), 44100, 14700},
+		{Seconds(0.00001), 44100, 0}, // rounded to the nearest frame
+		{Seconds(0.00002), 44100, 1},
+		{Samples(12345), 8000, 12345},
+	}
+	for _, test := range tests {
+		found := test.time.Frames(test.sampleRate)
+		if found != test.expected {
+			t.Errorf("%s at %d Hz: expected %d frames, found %d", test.time, test.sampleRate, test.expected, found)
+		}
+	}
+}
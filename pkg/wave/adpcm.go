@@ -0,0 +1,246 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	imaADPCMIndexTable = [16]int{-1, -1, -1, -1, 2, 4, 6, 8, -1, -1, -1, -1, 2, 4, 6, 8}
+	imaADPCMStepTable  = [89]int{
+		7, 8, 9, 10, 11, 12, 13, 14, 16, 17, 19, 21, 23, 25, 28, 31, 34, 37, 41, 45, 50, 55, 60, 66, 73, 80, 88, 97,
+		107, 118, 130, 143, 157, 173, 190, 209, 230, 253, 279, 307, 337, 371, 408, 449, 494, 544, 598, 658, 724, 796,
+		876, 963, 1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066, 2272, 2499, 2749, 3024, 3327, 3660, 4026, 4428, 4871,
+		5358, 5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487, 12635, 13899, 15289, 16818, 18500, 20350, 22385, 24623,
+		27086, 29794, 32767,
+	}
+
+	msADPCMAdaptationTable = [16]int{230, 230, 230, 230, 307, 409, 512, 614, 768, 614, 512, 409, 307, 230, 230, 230}
+	// msADPCMDefaultCoefficients are the predictor coefficients every MS ADPCM fmt sub-chunk starts with.
+	msADPCMDefaultCoefficients = [][2]int{{256, 0}, {512, -256}, {0, 0}, {192, 64}, {240, 0}, {460, -208}, {392, -232}}
+)
+
+// adpcmDecoder decodes blocks of ADPCM data to 16-bit linear PCM.
+type adpcmDecoder interface {
+	// decodeBlock decodes a block, which may be truncated, into dst, interleaved. dst must hold SamplesPerBlock
+	// frames. Returns the number of frames decoded.
+	decodeBlock(block []byte, dst []int16) (int, error)
+}
+
+type imaADPCMDecoder struct {
+	numChannels int
+	byteOrder   binary.ByteOrder
+}
+
+type msADPCMDecoder struct {
+	numChannels  int
+	byteOrder    binary.ByteOrder
+	coefficients [][2]int
+}
+
+// newADPCMDecoder returns the decoder for an ADPCM format, or nil if the format is not ADPCM.
+func newADPCMDecoder(waveFmt *WaveFmt, byteOrder binary.ByteOrder) adpcmDecoder {
+	switch waveFmt.SampleFormat() {
+	case FormatIMAADPCM:
+		return &imaADPCMDecoder{
+			numChannels: waveFmt.NumChannels,
+			byteOrder:   byteOrder,
+		}
+	case FormatMSADPCM:
+		coefficients := waveFmt.Coefficients
+		if len(coefficients) == 0 {
+			coefficients = msADPCMDefaultCoefficients
+		}
+		return &msADPCMDecoder{
+			numChannels:  waveFmt.NumChannels,
+			byteOrder:    byteOrder,
+			coefficients: coefficients,
+		}
+	default:
+		return nil
+	}
+}
+
+// adpcmSamplesPerBlock returns the number of frames in a block of the specified size.
+func adpcmSamplesPerBlock(audioFormat int, numChannels int, blockSize int) int {
+	switch audioFormat {
+	case FormatIMAADPCM:
+		headerSize := 4 * numChannels
+		if blockSize < headerSize {
+			return 0
+		}
+		// the data is interleaved in 4-byte words of 8 samples per channel
+		return 1 + (blockSize-headerSize)/(4*numChannels)*8
+	case FormatMSADPCM:
+		headerSize := 7 * numChannels
+		if blockSize < headerSize {
+			return 0
+		}
+		return 2 + (blockSize-headerSize)*2/numChannels
+	default:
+		return 0
+	}
+}
+
+// parseADPCMParams parses the samples per block and, for MS ADPCM, the coefficient table from the extra params of the
+// fmt sub-chunk.
+func parseADPCMParams(waveFmt *WaveFmt, byteOrder binary.ByteOrder) {
+	extraParams := waveFmt.ExtraParams
+	if waveFmt.AudioFormat == FormatExtensible {
+		// WAVE_FORMAT_EXTENSIBLE: the samples per block are in place of the valid bits per sample, and the
+		// coefficient table follows the sub-format
+		waveFmt.ValidBitsPerSample = 0
+		extraParams = append(extraParams[0:2:2], extraParams[22:]...)
+	}
+	if len(extraParams) >= 2 {
+		waveFmt.SamplesPerBlock = int(byteOrder.Uint16(extraParams[0:2]))
+	}
+	if (waveFmt.SampleFormat() == FormatMSADPCM) && (len(extraParams) >= 4) {
+		numCoefficients := int(byteOrder.Uint16(extraParams[2:4]))
+		for i := 0; (i < numCoefficients) && (len(extraParams) >= 4+(i+1)*4); i++ {
+			offset := 4 + i*4
+			waveFmt.Coefficients = append(waveFmt.Coefficients, [2]int{
+				int(int16(byteOrder.Uint16(extraParams[offset : offset+2]))),
+				int(int16(byteOrder.Uint16(extraParams[offset+2 : offset+4]))),
+			})
+		}
+	}
+}
+
+func validateADPCMFmt(waveFmt *WaveFmt) error {
+	if waveFmt.BitsPerSample != 4 {
+		return errors.New(fmt.Sprintf("unsupported bits per sample for ADPCM: %d", waveFmt.BitsPerSample))
+	}
+	samplesPerBlock := adpcmSamplesPerBlock(waveFmt.SampleFormat(), waveFmt.NumChannels, waveFmt.BlockAlign)
+	if samplesPerBlock < 1 {
+		return errors.New(fmt.Sprintf("invalid block align: %d", waveFmt.BlockAlign))
+	}
+	if waveFmt.SamplesPerBlock > samplesPerBlock {
+		return errors.New(fmt.Sprintf("samples per block (%d) exceeds block capacity (%d)",
+			waveFmt.SamplesPerBlock, samplesPerBlock))
+	}
+	return nil
+}
+
+func (d *imaADPCMDecoder) decodeBlock(block []byte, dst []int16) (int, error) {
+	numChannels := d.numChannels
+	numFrames := adpcmSamplesPerBlock(FormatIMAADPCM, numChannels, len(block))
+	if numFrames > len(dst)/numChannels {
+		numFrames = len(dst) / numChannels
+	}
+	if numFrames == 0 {
+		return 0, nil
+	}
+
+	for j := 0; j < numChannels; j++ {
+		header := block[j*4 : (j+1)*4]
+		predictor := int(int16(d.byteOrder.Uint16(header[0:2])))
+		index := int(header[2])
+		if index > 88 {
+			return 0, errors.New(fmt.Sprintf("invalid IMA ADPCM step index: %d", index))
+		}
+		dst[j] = int16(predictor)
+
+		// each word holds 8 samples of the channel, low nibble first
+		for i := 1; i < numFrames; i++ {
+			word := (i - 1) / 8
+			nibbleIndex := (i - 1) % 8
+			b := block[numChannels*4+(word*numChannels+j)*4+nibbleIndex/2]
+			nibble := b & 0x0f
+			if (nibbleIndex % 2) != 0 {
+				nibble = b >> 4
+			}
+
+			step := imaADPCMStepTable[index]
+			diff := step >> 3
+			if (nibble & 1) != 0 {
+				diff += step >> 2
+			}
+			if (nibble & 2) != 0 {
+				diff += step >> 1
+			}
+			if (nibble & 4) != 0 {
+				diff += step
+			}
+			if (nibble & 8) != 0 {
+				predictor -= diff
+			} else {
+				predictor += diff
+			}
+			predictor = clampInt16(predictor)
+			index += imaADPCMIndexTable[nibble]
+			if index < 0 {
+				index = 0
+			} else if index > 88 {
+				index = 88
+			}
+			dst[i*numChannels+j] = int16(predictor)
+		}
+	}
+	return numFrames, nil
+}
+
+func (d *msADPCMDecoder) decodeBlock(block []byte, dst []int16) (int, error) {
+	numChannels := d.numChannels
+	numFrames := adpcmSamplesPerBlock(FormatMSADPCM, numChannels, len(block))
+	if numFrames > len(dst)/numChannels {
+		numFrames = len(dst) / numChannels
+	}
+	if numFrames == 0 {
+		return 0, nil
+	}
+
+	// the header holds, for each field in turn, the value of each channel
+	coefficients := make([][2]int, numChannels)
+	deltas := make([]int, numChannels)
+	sample1 := make([]int, numChannels)
+	sample2 := make([]int, numChannels)
+	for j := 0; j < numChannels; j++ {
+		predictorIndex := int(block[j])
+		if predictorIndex >= len(d.coefficients) {
+			return 0, errors.New(fmt.Sprintf("invalid MS ADPCM predictor index: %d", predictorIndex))
+		}
+		coefficients[j] = d.coefficients[predictorIndex]
+		deltas[j] = int(int16(d.byteOrder.Uint16(block[numChannels+j*2:])))
+		sample1[j] = int(int16(d.byteOrder.Uint16(block[3*numChannels+j*2:])))
+		sample2[j] = int(int16(d.byteOrder.Uint16(block[5*numChannels+j*2:])))
+		dst[j] = int16(sample2[j])
+		if numFrames > 1 {
+			dst[numChannels+j] = int16(sample1[j])
+		}
+	}
+
+	// the nibbles are interleaved by channel, high nibble first
+	data := block[7*numChannels:]
+	for k := 0; k < (numFrames-2)*numChannels; k++ {
+		nibble := data[k/2] >> 4
+		if (k % 2) != 0 {
+			nibble = data[k/2] & 0x0f
+		}
+		j := k % numChannels
+		signed := int(nibble)
+		if signed >= 8 {
+			signed -= 16
+		}
+		predictor := (sample1[j]*coefficients[j][0] + sample2[j]*coefficients[j][1]) >> 8
+		predictor = clampInt16(predictor + signed*deltas[j])
+		sample2[j] = sample1[j]
+		sample1[j] = predictor
+		deltas[j] = (msADPCMAdaptationTable[nibble] * deltas[j]) >> 8
+		if deltas[j] < 16 {
+			deltas[j] = 16
+		}
+		dst[2*numChannels+k] = int16(predictor)
+	}
+	return numFrames, nil
+}
+
+func clampInt16(v int) int {
+	if v > 32767 {
+		return 32767
+	} else if v < -32768 {
+		return -32768
+	}
+	return v
+}
@@ -0,0 +1,217 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+)
+
+// The fixtures in testdata are stereo files of 1000 frames according to the fact sub-chunk, with 256-byte blocks and
+// a shorter last block holding more frames than the remaining 4 (IMA) or 24 (MS). The .pcm files hold the expected
+// frames as 16-bit little-endian PCM: IMA ADPCM from Python's audioop, MS ADPCM from a reference decoder.
+var adpcmFixtures = []string{"ima_adpcm", "ms_adpcm"}
+
+// readFixture returns the ADPCM file and the expected frames of a fixture.
+func readFixture(t *testing.T, name string) ([]byte, []float64) {
+	file, err := os.ReadFile("testdata/" + name + ".wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := os.ReadFile("testdata/" + name + ".pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make([]float64, len(pcm)/2)
+	for i := range expected {
+		expected[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+	}
+	return file, expected
+}
+
+// compareFrames reads the remaining frames of the decoder and compares them with expected.
+func compareFrames(t *testing.T, decoder *Decoder, expected []float64) {
+	numChannels := decoder.Wave().Fmt.NumChannels
+	found := make([]float64, 0)
+	// an odd size, so that reads end within blocks
+	buffer := make([]float64, 37*numChannels)
+	for {
+		n, err := decoder.ReadFrames(buffer)
+		found = append(found, buffer[:n*numChannels]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d frames, found %d", len(expected)/numChannels, len(found)/numChannels)
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Fatalf("frame %d, channel %d: expected %g, found %g", i/numChannels, i%numChannels, expected[i],
+				found[i])
+		}
+	}
+}
+
+func TestADPCMDecoder(t *testing.T) {
+	for _, name := range adpcmFixtures {
+		t.Run(name, func(t *testing.T) {
+			file, expected := readFixture(t, name)
+			decoder, err := NewDecoder(bytes.NewReader(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the fact sub-chunk truncates the last block
+			if decoder.NumFrames() != 1000 {
+				t.Fatalf("expected 1000 frames, found %d", decoder.NumFrames())
+			}
+			compareFrames(t, decoder, expected)
+		})
+	}
+}
+
+// extensibleFixture rewrites the fmt sub-chunk of an ADPCM fixture as WAVE_FORMAT_EXTENSIBLE, with the samples per
+// block in place of the valid bits per sample and the coefficient table after the sub-format.
+func extensibleFixture(file []byte) []byte {
+	fmtSize := int(binary.LittleEndian.Uint32(file[16:]))
+	waveFmt := file[20 : 20+fmtSize]
+	subFormat := formatGUIDBase
+	subFormat.Data1 = uint32(binary.LittleEndian.Uint16(waveFmt[0:]))
+	extensible := binary.LittleEndian.AppendUint16(nil, FormatExtensible)
+	extensible = append(extensible, waveFmt[2:16]...)
+	extensible = binary.LittleEndian.AppendUint16(extensible, uint16(fmtSize+2))
+	extensible = append(extensible, waveFmt[18:20]...)
+	extensible = binary.LittleEndian.AppendUint32(extensible, 0x3) // FL, FR
+	extensible = append(extensible, guidBytes(subFormat)...)
+	extensible = append(extensible, waveFmt[20:]...)
+	return riffFile(riffChunk("fmt ", extensible), file[20+fmtSize:])
+}
+
+func TestADPCMDecoderExtensible(t *testing.T) {
+	for _, name := range adpcmFixtures {
+		t.Run(name, func(t *testing.T) {
+			file, expected := readFixture(t, name)
+			decoder, err := NewDecoder(bytes.NewReader(extensibleFixture(file)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			waveFmt := decoder.Wave().Fmt
+			samplesPerBlock := int(binary.LittleEndian.Uint16(file[38:]))
+			if (waveFmt.SamplesPerBlock != samplesPerBlock) || (waveFmt.ValidBitsPerSample != 0) {
+				t.Errorf("expected %d samples per block, found %d and %d valid bits per sample", samplesPerBlock,
+					waveFmt.SamplesPerBlock, waveFmt.ValidBitsPerSample)
+			}
+			if decoder.NumFrames() != 1000 {
+				t.Fatalf("expected 1000 frames, found %d", decoder.NumFrames())
+			}
+			compareFrames(t, decoder, expected)
+		})
+	}
+}
+
+func TestADPCMDecoderSeekFrame(t *testing.T) {
+	for _, name := range adpcmFixtures {
+		t.Run(name, func(t *testing.T) {
+			file, expected := readFixture(t, name)
+			decoder, err := NewDecoder(bytes.NewReader(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the middle of the second block, the start of the last block, within the last block, and the end
+			for _, frame := range []int{300, 996, 998, 1000, 0} {
+				err = decoder.SeekFrame(int64(frame))
+				if err != nil {
+					t.Fatal(err)
+				}
+				compareFrames(t, decoder, expected[2*frame:])
+			}
+		})
+	}
+}
+
+func TestADPCMDecoderWithoutFact(t *testing.T) {
+	file, expected := readFixture(t, "ima_adpcm")
+	// the 12-byte fact sub-chunk follows the fmt sub-chunk
+	i := bytes.Index(file, []byte("fact"))
+	file = append(file[:i:i], file[i+12:]...)
+	binary.LittleEndian.PutUint32(file[4:], uint32(len(file)-8))
+
+	decoder, err := NewDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all the frames of the 16-byte last block
+	if decoder.NumFrames() != 996+9 {
+		t.Fatalf("expected %d frames, found %d", 996+9, decoder.NumFrames())
+	}
+	buffer := make([]float64, 2*int(decoder.NumFrames()))
+	n, err := decoder.ReadFrames(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int(decoder.NumFrames()) {
+		t.Fatalf("expected %d frames, read %d", decoder.NumFrames(), n)
+	}
+	for i := range expected {
+		if buffer[i] != expected[i] {
+			t.Fatalf("sample %d: expected %g, found %g", i, expected[i], buffer[i])
+		}
+	}
+}
+
+func TestIMAADPCMNibbleOrder(t *testing.T) {
+	// the low nibble of each byte is the earlier sample, and each channel has its own 4-byte words
+	block := []byte{
+		0x10, 0x00, 0, 0, // channel 0: predictor 16, step index 0
+		0x20, 0x00, 0, 0, // channel 1: predictor 32, step index 0
+		0x14, 0x00, 0x00, 0x00, // channel 0: nibbles 4, 1, then 0
+		0x0c, 0x00, 0x00, 0x00, // channel 1: nibbles 12, 0, then 0
+	}
+	decoder := &imaADPCMDecoder{numChannels: 2, byteOrder: binary.LittleEndian}
+	dst := make([]int16, 2*9)
+	n, err := decoder.decodeBlock(block, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 9 {
+		t.Fatalf("expected 9 frames, found %d", n)
+	}
+	// channel 0: 16, 16 + 7 with a step of 7, then 23 + (9 >> 2) + (9 >> 3) with a step of 9
+	// channel 1: 32, 32 - 7 with a step of 7, then 25 + (9 >> 3) with a step of 9
+	expected := []int16{16, 32, 23, 25, 26, 26}
+	for i, val := range expected {
+		if dst[i] != val {
+			t.Fatalf("sample %d: expected %d, found %d (%v)", i, val, dst[i], dst[:len(expected)])
+		}
+	}
+}
+
+func TestMSADPCMHeaderOrder(t *testing.T) {
+	block := []byte{
+		0, 0, // predictor indices
+		16, 0, 16, 0, // deltas
+		0xe8, 0x03, 0xd0, 0x07, // sample1: 1000, 2000
+		0x18, 0xfc, 0x30, 0xf8, // sample2: -1000, -2000
+		0x00, // nibbles of the third frame
+	}
+	decoder := &msADPCMDecoder{numChannels: 2, byteOrder: binary.LittleEndian, coefficients: msADPCMDefaultCoefficients}
+	dst := make([]int16, 2*3)
+	n, err := decoder.decodeBlock(block, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 frames, found %d", n)
+	}
+	// sample2 is the first frame; coefficients {256, 0} repeat sample1
+	expected := []int16{-1000, -2000, 1000, 2000, 1000, 2000}
+	for i, val := range expected {
+		if dst[i] != val {
+			t.Fatalf("sample %d: expected %d, found %d (%v)", i, val, dst[i], dst)
+		}
+	}
+}
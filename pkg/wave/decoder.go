@@ -0,0 +1,307 @@
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// decoderBufferFrames is the number of frames decoded at a time when reading the whole data sub-chunk.
+const decoderBufferFrames = 4096
+
+// ErrNotSeekable is returned by Decoder.SeekFrame when the reader is not an io.ReadSeeker, or cannot seek.
+var ErrNotSeekable = errors.New("reader is not seekable")
+
+// Decoder decodes the frames of the data sub-chunk on demand, so that the file need not be held in memory.
+type Decoder struct {
+	wave         *Wave
+	reader       io.Reader
+	sampleReader *sampleReader
+	numFrames    int64
+	buffer       []byte
+	adpcm        *adpcmBlockReader
+	seeker       io.ReadSeeker // nil if the reader cannot seek
+	dataStart    int64         // offset of the data sub-chunk contents
+	dataSize     uint64
+}
+
+// adpcmBlockReader holds the current decoded block of an ADPCM data sub-chunk.
+type adpcmBlockReader struct {
+	decoder         adpcmDecoder
+	samplesPerBlock int
+	remaining       int64 // frames left to return
+	samples         []int16
+	numFrames       int
+	pos             int
+}
+
+// FileDecoder is a Decoder that owns the underlying file.
+type FileDecoder struct {
+	*Decoder
+	file *os.File
+}
+
+func OpenDecoderFromFile(f string) (*FileDecoder, error) {
+	return openFileDecoder(f, NewDecoder)
+}
+
+func OpenWave64DecoderFromFile(f string) (*FileDecoder, error) {
+	return openFileDecoder(f, NewWave64Decoder)
+}
+
+func openFileDecoder(f string, newDecoder func(io.Reader) (*Decoder, error)) (*FileDecoder, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := newDecoder(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileDecoder{
+		Decoder: decoder,
+		file:    file,
+	}, nil
+}
+
+func (fd *FileDecoder) Close() error {
+	return fd.file.Close()
+}
+
+// NewDecoder reads the header and the sub-chunks preceding the data sub-chunk of a RIFF, RIFX, RF64 or BW64 file.
+func NewDecoder(reader io.Reader) (*Decoder, error) {
+	waveReader, chunks, err := newWaveReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := waveReader.openDecoder(chunks)
+	if err != nil {
+		return nil, err
+	}
+	decoder.initSeeker(reader)
+	return decoder, nil
+}
+
+// NewWave64Decoder reads the header and the sub-chunks preceding the data sub-chunk of a Sony Wave64 file.
+func NewWave64Decoder(reader io.Reader) (*Decoder, error) {
+	waveReader, err := newW64WaveReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := waveReader.openDecoder(make([]*Chunk, 0))
+	if err != nil {
+		return nil, err
+	}
+	decoder.initSeeker(reader)
+	return decoder, nil
+}
+
+func (wr *waveReader) openDecoder(chunks []*Chunk) (*Decoder, error) {
+	wave := wr.newWave(chunks)
+	dataReader, dataSize, err := wr.readSubChunksUntilData(wave, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if dataReader == nil {
+		return nil, errors.New(fmt.Sprintf("missing sub-chunk %s", string(dataSubChunkId)))
+	}
+
+	return wr.newDecoder(wave, dataReader, dataSize), nil
+}
+
+func (wr *waveReader) newDecoder(wave *Wave, dataReader io.Reader, dataSize uint64) *Decoder {
+	decoder := &Decoder{
+		wave:         wave,
+		reader:       dataReader,
+		sampleReader: newSampleReader(wave.Fmt, wr.byteOrder),
+		numFrames:    int64(dataSize / uint64(wave.Fmt.BlockAlign)),
+		dataSize:     dataSize,
+	}
+
+	adpcm := newADPCMDecoder(wave.Fmt, wr.byteOrder)
+	if adpcm != nil {
+		waveFmt := wave.Fmt
+		samplesPerBlock := adpcmSamplesPerBlock(waveFmt.SampleFormat(), waveFmt.NumChannels, waveFmt.BlockAlign)
+		if waveFmt.SamplesPerBlock > 0 {
+			samplesPerBlock = waveFmt.SamplesPerBlock
+		}
+		lastBlockSize := int(dataSize % uint64(waveFmt.BlockAlign))
+		lastBlockFrames := adpcmSamplesPerBlock(waveFmt.SampleFormat(), waveFmt.NumChannels, lastBlockSize)
+		if lastBlockFrames > samplesPerBlock {
+			lastBlockFrames = samplesPerBlock
+		}
+		decoder.numFrames = decoder.numFrames*int64(samplesPerBlock) + int64(lastBlockFrames)
+		if (wave.Fact != nil) && (int64(wave.Fact.SampleLength) < decoder.numFrames) {
+			// the last block is padded; the fact sub-chunk has the actual length
+			decoder.numFrames = int64(wave.Fact.SampleLength)
+		}
+		decoder.adpcm = &adpcmBlockReader{
+			decoder:         adpcm,
+			samplesPerBlock: samplesPerBlock,
+			remaining:       decoder.numFrames,
+			samples:         make([]int16, samplesPerBlock*waveFmt.NumChannels),
+		}
+	}
+
+	return decoder
+}
+
+// initSeeker enables seeking if reader is an io.ReadSeeker, which is positioned at the start of the data sub-chunk
+// contents.
+func (d *Decoder) initSeeker(reader io.Reader) {
+	seeker, ok := reader.(io.ReadSeeker)
+	if !ok {
+		return
+	}
+	dataStart, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// e.g. a pipe
+		return
+	}
+	d.seeker = seeker
+	d.dataStart = dataStart
+}
+
+// Wave returns the header and the metadata sub-chunks preceding the data sub-chunk. Frames is always nil.
+func (d *Decoder) Wave() *Wave {
+	return d.wave
+}
+
+// NumFrames returns the number of frames in the data sub-chunk, according to its size.
+func (d *Decoder) NumFrames() int64 {
+	return d.numFrames
+}
+
+// ReadFrames decodes up to len(buffer) / NumChannels frames into buffer, interleaved. Returns the number of frames
+// decoded, or io.EOF when there are no more frames. A truncated trailing frame is dropped.
+func (d *Decoder) ReadFrames(buffer []float64) (int, error) {
+	if d.adpcm != nil {
+		return d.readADPCMFrames(buffer)
+	}
+
+	waveFmt := d.wave.Fmt
+	numFrames := len(buffer) / waveFmt.NumChannels
+	size := numFrames * waveFmt.BlockAlign
+	if len(d.buffer) < size {
+		d.buffer = make([]byte, size)
+	}
+
+	n, err := io.ReadFull(d.reader, d.buffer[:size])
+	numFrames = n / waveFmt.BlockAlign
+	if (err == io.EOF) || (err == io.ErrUnexpectedEOF) {
+		if numFrames == 0 {
+			return 0, io.EOF
+		}
+		err = nil
+	}
+
+	bytesPerSample := d.sampleReader.bytesPerSample
+	for i := 0; i < numFrames; i++ {
+		frame := d.buffer[i*waveFmt.BlockAlign : (i+1)*waveFmt.BlockAlign]
+		for j := 0; j < waveFmt.NumChannels; j++ {
+			start := j * bytesPerSample
+			end := start + bytesPerSample
+			val, err := d.sampleReader.readSample(frame[start:end])
+			if err != nil {
+				return i, err
+			}
+			buffer[i*waveFmt.NumChannels+j] = val
+		}
+	}
+
+	return numFrames, err
+}
+
+// SeekFrame positions the decoder so that the next frame decoded is frame, without decoding the preceding frames.
+// Returns ErrNotSeekable unless the reader passed to NewDecoder is an io.ReadSeeker. ADPCM data is positioned at the
+// start of the block containing frame, which is decoded up to frame.
+func (d *Decoder) SeekFrame(frame int64) error {
+	if d.seeker == nil {
+		return ErrNotSeekable
+	}
+	if (frame < 0) || (frame > d.numFrames) {
+		return errors.New(fmt.Sprintf("cannot seek to frame %d of %d", frame, d.numFrames))
+	}
+
+	blockAlign := int64(d.wave.Fmt.BlockAlign)
+	if d.adpcm == nil {
+		return d.seekData(frame * blockAlign)
+	}
+
+	samplesPerBlock := int64(d.adpcm.samplesPerBlock)
+	block := frame / samplesPerBlock
+	err := d.seekData(block * blockAlign)
+	if err != nil {
+		return err
+	}
+	d.adpcm.remaining = d.numFrames - block*samplesPerBlock
+	d.adpcm.numFrames = 0
+	d.adpcm.pos = 0
+
+	skip := int(frame - block*samplesPerBlock)
+	if skip > 0 {
+		_, err = d.readADPCMFrames(make([]float64, skip*d.wave.Fmt.NumChannels))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seekData positions the reader at offset bytes into the data sub-chunk contents.
+func (d *Decoder) seekData(offset int64) error {
+	_, err := d.seeker.Seek(d.dataStart+offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	d.reader = io.LimitReader(d.seeker, int64(d.dataSize)-offset)
+	return nil
+}
+
+// readADPCMFrames decodes ADPCM blocks as needed, up to the length in the fact sub-chunk.
+func (d *Decoder) readADPCMFrames(buffer []float64) (int, error) {
+	numChannels := d.wave.Fmt.NumChannels
+	blockAlign := d.wave.Fmt.BlockAlign
+	if len(d.buffer) < blockAlign {
+		d.buffer = make([]byte, blockAlign)
+	}
+
+	n := 0
+	for (n < len(buffer)/numChannels) && (d.adpcm.remaining > 0) {
+		if d.adpcm.pos >= d.adpcm.numFrames {
+			size, err := io.ReadFull(d.reader, d.buffer[:blockAlign])
+			if (err == io.EOF) || (err == io.ErrUnexpectedEOF) {
+				err = nil
+			}
+			if err != nil {
+				return n, err
+			}
+			numFrames, err := d.adpcm.decoder.decodeBlock(d.buffer[:size], d.adpcm.samples)
+			if err != nil {
+				return n, err
+			}
+			if numFrames == 0 {
+				break
+			}
+			d.adpcm.numFrames = numFrames
+			d.adpcm.pos = 0
+		}
+		for j := 0; j < numChannels; j++ {
+			buffer[n*numChannels+j] = float64(d.adpcm.samples[d.adpcm.pos*numChannels+j]) / 32768
+		}
+		d.adpcm.pos++
+		d.adpcm.remaining--
+		n++
+	}
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
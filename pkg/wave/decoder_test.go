@@ -0,0 +1,62 @@
+package wave
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewDecoderRejectsDegenerateFmt(t *testing.T) {
+	tests := []struct {
+		name string
+		fmt  []byte
+	}{
+		{"PCM without channels", fmtChunk(FormatPCM, 0, 8000, 0, 16)},
+		{"PCM without block align", fmtChunk(FormatPCM, 1, 8000, 0, 16)},
+		{"float without channels", fmtChunk(FormatIEEEFloat, 0, 8000, 4, 32)},
+		{"float without block align", fmtChunk(FormatIEEEFloat, 1, 8000, 0, 32)},
+		{"A-law without channels", fmtChunk(FormatALaw, 0, 8000, 1, 8)},
+		{"mu-law without block align", fmtChunk(FormatMuLaw, 1, 8000, 0, 8)},
+		{"IMA ADPCM without channels", fmtChunk(FormatIMAADPCM, 0, 8000, 256, 4)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := riffFile(test.fmt, riffChunk("data", pcm16(1, 2, 3, 4)))
+			decoder, err := NewDecoder(bytes.NewReader(file))
+			if err == nil {
+				t.Fatalf("expected an error, decoded %d frames", decoder.NumFrames())
+			}
+		})
+	}
+}
+
+func TestDecoderReadFrames(t *testing.T) {
+	file := riffFile(fmtChunk(FormatPCM, 2, 8000, 4, 16), riffChunk("data", pcm16(0, 16384, -32768, 32767, 8192)))
+	decoder, err := NewDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoder.NumFrames() != 2 {
+		t.Fatalf("expected 2 frames, found %d", decoder.NumFrames())
+	}
+
+	buffer := make([]float64, 8)
+	n, err := decoder.ReadFrames(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the truncated trailing frame is dropped
+	expected := []float64{0, 16384.0 / 32767, -32768.0 / 32767, 1}
+	if n != 2 {
+		t.Fatalf("expected 2 frames, read %d", n)
+	}
+	for i, val := range expected {
+		if buffer[i] != val {
+			t.Errorf("sample %d: expected %g, found %g", i, val, buffer[i])
+		}
+	}
+	_, err = decoder.ReadFrames(buffer)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, found %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package wave
+
+// G.711 companded-to-linear expansion tables, indexed by the encoded byte. Values are 16-bit linear PCM.
+var (
+	aLawTable  [256]int16
+	muLawTable [256]int16
+)
+
+func init() {
+	for i := 0; i < 256; i++ {
+		aLawTable[i] = aLawToLinear(byte(i))
+		muLawTable[i] = muLawToLinear(byte(i))
+	}
+}
+
+func aLawToLinear(v byte) int16 {
+	v ^= 0x55
+	t := int16(v&0x0f) << 4
+	segment := (v & 0x70) >> 4
+	switch segment {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= segment - 1
+	}
+	if (v & 0x80) == 0 {
+		return -t
+	}
+	return t
+}
+
+func muLawToLinear(v byte) int16 {
+	const bias = 0x84
+	v = ^v
+	t := ((int16(v&0x0f) << 3) + bias) << ((v & 0x70) >> 4)
+	if (v & 0x80) != 0 {
+		return bias - t
+	}
+	return t - bias
+}
+
+// DecodeALaw expands an A-law encoded byte to 16-bit linear PCM.
+func DecodeALaw(v byte) int16 {
+	return aLawTable[v]
+}
+
+// DecodeMuLaw expands a µ-law encoded byte to 16-bit linear PCM.
+func DecodeMuLaw(v byte) int16 {
+	return muLawTable[v]
+}
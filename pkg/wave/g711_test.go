@@ -0,0 +1,53 @@
+package wave
+
+import (
+	"testing"
+)
+
+func TestDecodeG711(t *testing.T) {
+	// from ITU-T G.711, as expanded by Python's audioop
+	tests := []struct {
+		v     byte
+		aLaw  int16
+		muLaw int16
+	}{
+		{0x00, -5504, -32124},
+		{0x01, -5248, -31100},
+		{0x0f, -6784, -16764},
+		{0x2a, -32256, -5372},
+		{0x55, -8, -716},
+		{0x7f, -848, 0},
+		{0x80, 5504, 32124},
+		{0xaa, 32256, 5372},
+		{0xd5, 8, 716},
+		{0xf0, 688, 120},
+		{0xff, 848, 0},
+	}
+	for _, test := range tests {
+		if DecodeALaw(test.v) != test.aLaw {
+			t.Errorf("A-law 0x%02x: expected %d, found %d", test.v, test.aLaw, DecodeALaw(test.v))
+		}
+		if DecodeMuLaw(test.v) != test.muLaw {
+			t.Errorf("µ-law 0x%02x: expected %d, found %d", test.v, test.muLaw, DecodeMuLaw(test.v))
+		}
+	}
+
+	// the sign bit only negates
+	for i := 0; i < 128; i++ {
+		v := byte(i)
+		if DecodeALaw(v|0x80) != -DecodeALaw(v) {
+			t.Errorf("A-law 0x%02x: expected %d, found %d", v|0x80, -DecodeALaw(v), DecodeALaw(v|0x80))
+		}
+		if DecodeMuLaw(v|0x80) != -DecodeMuLaw(v) {
+			t.Errorf("µ-law 0x%02x: expected %d, found %d", v|0x80, -DecodeMuLaw(v), DecodeMuLaw(v|0x80))
+		}
+	}
+}
+
+func TestReadG711(t *testing.T) {
+	data := []byte{0x00, 0x55, 0xd5, 0xaa}
+	compareSamples(t, "A-law", readFrames(t, riffFile(fmtChunk(FormatALaw, 2, 8000, 2, 8), riffChunk("data", data))),
+		[]float64{-5504.0 / 32768, -8.0 / 32768, 8.0 / 32768, 32256.0 / 32768})
+	compareSamples(t, "µ-law", readFrames(t, riffFile(fmtChunk(FormatMuLaw, 2, 8000, 2, 8), riffChunk("data", data))),
+		[]float64{-32124.0 / 32768, -716.0 / 32768, 716.0 / 32768, 5372.0 / 32768})
+}
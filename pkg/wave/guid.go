@@ -0,0 +1,44 @@
+package wave
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+type GUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// formatGUIDBase is the common suffix of the KSDATAFORMAT_SUBTYPE_* GUIDs; Data1 holds the audio format.
+var formatGUIDBase = GUID{
+	Data2: 0x0000,
+	Data3: 0x0010,
+	Data4: [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71},
+}
+
+func parseGUID(b []byte, byteOrder binary.ByteOrder) GUID {
+	var guid GUID
+	guid.Data1 = byteOrder.Uint32(b[0:4])
+	guid.Data2 = byteOrder.Uint16(b[4:6])
+	guid.Data3 = byteOrder.Uint16(b[6:8])
+	copy(guid.Data4[:], b[8:16])
+	return guid
+}
+
+// AudioFormat returns the audio format encoded in a KSDATAFORMAT_SUBTYPE_* GUID, or false if the GUID is not one.
+func (guid GUID) AudioFormat() (int, bool) {
+	if (guid.Data2 != formatGUIDBase.Data2) || (guid.Data3 != formatGUIDBase.Data3) || (guid.Data4 != formatGUIDBase.Data4) {
+		return 0, false
+	}
+	if guid.Data1 > 0xffff {
+		return 0, false
+	}
+	return int(guid.Data1), true
+}
+
+func (guid GUID) String() string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x", guid.Data1, guid.Data2, guid.Data3, guid.Data4[0:2], guid.Data4[2:8])
+}
@@ -0,0 +1,190 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Chunk struct {
+	ID   string
+	Size int64
+	Data []byte
+}
+
+type List struct {
+	Type   string
+	Chunks []*Chunk
+}
+
+// Bext is the Broadcast Wave Format (EBU Tech 3285) extension.
+type Bext struct {
+	Description          string
+	Originator           string
+	OriginatorReference  string
+	OriginationDate      string // yyyy-mm-dd
+	OriginationTime      string // hh:mm:ss
+	TimeReference        uint64 // first sample count since midnight
+	Version              int
+	UMID                 []byte
+	LoudnessValue        int16 // version 2 only, LUFS * 100
+	LoudnessRange        int16 // version 2 only, LU * 100
+	MaxTruePeakLevel     int16 // version 2 only, dBTP * 100
+	MaxMomentaryLoudness int16 // version 2 only, LUFS * 100
+	MaxShortTermLoudness int16 // version 2 only, LUFS * 100
+	CodingHistory        string
+}
+
+// Ds64 holds the 64-bit sizes of an RF64/BW64 file, whose 32-bit size fields are set to 0xFFFFFFFF.
+type Ds64 struct {
+	RiffSize    uint64
+	DataSize    uint64
+	SampleCount uint64
+	Table       map[string]uint64 // sizes of other sub-chunks larger than 4 GiB
+}
+
+type CuePoint struct {
+	ID           uint32
+	Position     uint32
+	DataChunkID  string
+	ChunkStart   uint32
+	BlockStart   uint32
+	SampleOffset uint32
+}
+
+type Label struct {
+	ID    string // "labl" or "note"
+	CueID uint32
+	Text  string
+}
+
+const bextMinSize = 602
+
+func parseList(data []byte, byteOrder binary.ByteOrder) (*List, error) {
+	if len(data) < 4 {
+		return nil, errors.New("LIST sub-chunk too small")
+	}
+	list := &List{
+		Type:   string(data[0:4]),
+		Chunks: make([]*Chunk, 0),
+	}
+	for offset := 4; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(byteOrder.Uint32(data[offset+4 : offset+8]))
+		offset += 8
+		if size > len(data)-offset {
+			return nil, errors.New(fmt.Sprintf("LIST item %s size too big", id))
+		}
+		list.Chunks = append(list.Chunks, &Chunk{
+			ID:   id,
+			Size: int64(size),
+			Data: data[offset : offset+size],
+		})
+		offset += size + (size % 2)
+	}
+	return list, nil
+}
+
+func parseDs64(data []byte, byteOrder binary.ByteOrder) (*Ds64, error) {
+	if len(data) < 28 {
+		return nil, errors.New("ds64 sub-chunk too small")
+	}
+	ds64 := &Ds64{
+		RiffSize:    byteOrder.Uint64(data[0:8]),
+		DataSize:    byteOrder.Uint64(data[8:16]),
+		SampleCount: byteOrder.Uint64(data[16:24]),
+		Table:       make(map[string]uint64),
+	}
+	tableLength := int(byteOrder.Uint32(data[24:28]))
+	if tableLength > (len(data)-28)/12 {
+		return nil, errors.New(fmt.Sprintf("ds64 sub-chunk too small for %d table entries", tableLength))
+	}
+	for i := 0; i < tableLength; i++ {
+		entry := data[28+i*12 : 28+(i+1)*12]
+		ds64.Table[string(entry[0:4])] = byteOrder.Uint64(entry[4:12])
+	}
+	return ds64, nil
+}
+
+// SubChunkSize returns the 64-bit size of the specified sub-chunk.
+func (ds64 *Ds64) SubChunkSize(id string) (uint64, bool) {
+	if id == string(dataSubChunkId) {
+		return ds64.DataSize, true
+	}
+	size, ok := ds64.Table[id]
+	return size, ok
+}
+
+func parseLabels(chunks []*Chunk, byteOrder binary.ByteOrder) []*Label {
+	labels := make([]*Label, 0)
+	for _, chunk := range chunks {
+		if ((chunk.ID != string(lablSubChunkId)) && (chunk.ID != "note")) || (len(chunk.Data) < 4) {
+			continue
+		}
+		labels = append(labels, &Label{
+			ID:    chunk.ID,
+			CueID: byteOrder.Uint32(chunk.Data[0:4]),
+			Text:  parseString(chunk.Data[4:]),
+		})
+	}
+	return labels
+}
+
+func parseBext(data []byte, byteOrder binary.ByteOrder) (*Bext, error) {
+	if len(data) < bextMinSize {
+		return nil, errors.New("bext sub-chunk too small")
+	}
+	bext := &Bext{
+		Description:         parseString(data[0:256]),
+		Originator:          parseString(data[256:288]),
+		OriginatorReference: parseString(data[288:320]),
+		OriginationDate:     parseString(data[320:330]),
+		OriginationTime:     parseString(data[330:338]),
+		TimeReference:       uint64(byteOrder.Uint32(data[338:342])) | (uint64(byteOrder.Uint32(data[342:346])) << 32),
+		Version:             int(byteOrder.Uint16(data[346:348])),
+		UMID:                data[348:412],
+		CodingHistory:       parseString(data[bextMinSize:]),
+	}
+	if bext.Version >= 2 {
+		bext.LoudnessValue = int16(byteOrder.Uint16(data[412:414]))
+		bext.LoudnessRange = int16(byteOrder.Uint16(data[414:416]))
+		bext.MaxTruePeakLevel = int16(byteOrder.Uint16(data[416:418]))
+		bext.MaxMomentaryLoudness = int16(byteOrder.Uint16(data[418:420]))
+		bext.MaxShortTermLoudness = int16(byteOrder.Uint16(data[420:422]))
+	}
+	return bext, nil
+}
+
+func parseCuePoints(data []byte, byteOrder binary.ByteOrder) ([]*CuePoint, error) {
+	if len(data) < 4 {
+		return nil, errors.New("cue sub-chunk too small")
+	}
+	count := int(byteOrder.Uint32(data[0:4]))
+	if count > (len(data)-4)/24 {
+		return nil, errors.New(fmt.Sprintf("cue sub-chunk too small for %d cue points", count))
+	}
+	cuePoints := make([]*CuePoint, count)
+	for i := 0; i < count; i++ {
+		entry := data[4+i*24 : 4+(i+1)*24]
+		cuePoints[i] = &CuePoint{
+			ID:           byteOrder.Uint32(entry[0:4]),
+			Position:     byteOrder.Uint32(entry[4:8]),
+			DataChunkID:  string(entry[8:12]),
+			ChunkStart:   byteOrder.Uint32(entry[12:16]),
+			BlockStart:   byteOrder.Uint32(entry[16:20]),
+			SampleOffset: byteOrder.Uint32(entry[20:24]),
+		}
+	}
+	return cuePoints, nil
+}
+
+// parseString decodes a NUL-terminated or NUL-padded string.
+func parseString(data []byte) string {
+	s := string(data)
+	i := strings.IndexByte(s, 0)
+	if i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestReadWaveMetadata(t *testing.T) {
+	info := append([]byte("INFO"), riffChunk("INAM", []byte("Title\x00"))...)
+	cue := make([]byte, 4+24)
+	binary.LittleEndian.PutUint32(cue[0:], 1)
+	binary.LittleEndian.PutUint32(cue[4:], 7)     // ID
+	binary.LittleEndian.PutUint32(cue[4+20:], 42) // sample offset
+	file := riffFile(
+		fmtChunk(FormatPCM, 1, 8000, 2, 16),
+		riffChunk("LIST", info),
+		riffChunk("cue ", cue),
+		riffChunk("abcd", []byte{1, 2, 3}),
+		riffChunk("data", pcm16(1, 2)),
+		riffChunk("iXML", []byte("<BWFXML/>")),
+	)
+
+	wave, err := ReadWaveFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wave.Info["INAM"] != "Title" {
+		t.Errorf("expected INAM Title, found %q", wave.Info["INAM"])
+	}
+	if (len(wave.CuePoints) != 1) || (wave.CuePoints[0].ID != 7) || (wave.CuePoints[0].SampleOffset != 42) {
+		t.Errorf("unexpected cue points: %+v", wave.CuePoints)
+	}
+	if wave.IXML != "<BWFXML/>" {
+		t.Errorf("expected the iXML after the data sub-chunk, found %q", wave.IXML)
+	}
+	ids := make([]string, 0)
+	for _, chunk := range wave.Chunks {
+		ids = append(ids, chunk.ID)
+	}
+	expectedIds := []string{"fmt ", "LIST", "cue ", "abcd", "data", "iXML"}
+	if len(ids) != len(expectedIds) {
+		t.Fatalf("expected sub-chunks %q, found %q", expectedIds, ids)
+	}
+	for i := range ids {
+		if ids[i] != expectedIds[i] {
+			t.Fatalf("expected sub-chunks %q, found %q", expectedIds, ids)
+		}
+	}
+	// unknown sub-chunks are skipped
+	if (wave.Chunks[3].Data != nil) || (wave.Chunks[3].Size != 3) {
+		t.Errorf("unexpected unknown sub-chunk: %+v", wave.Chunks[3])
+	}
+
+	// the data sub-chunk is seeked past, or ends the header if the reader cannot seek
+	wave, err = ReadWaveFromReader(bytes.NewReader(file), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (wave.IXML != "<BWFXML/>") || (wave.Frames != nil) {
+		t.Errorf("expected the iXML after the data sub-chunk and no frames, found %q and %d frames", wave.IXML,
+			len(wave.Frames))
+	}
+	wave, err = ReadWaveFromReader(struct{ io.Reader }{bytes.NewReader(file)}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(wave.Chunks) != 5) || (wave.IXML != "") || (wave.Info["INAM"] != "Title") {
+		t.Errorf("expected the sub-chunks up to the data sub-chunk, found %d sub-chunks and iXML %q",
+			len(wave.Chunks), wave.IXML)
+	}
+}
+
+func TestReadWaveToleratesMalformedMetadata(t *testing.T) {
+	file := riffFile(
+		fmtChunk(FormatPCM, 1, 8000, 2, 16),
+		riffChunk("LIST", []byte("IN")),
+		riffChunk("bext", make([]byte, 10)),
+		riffChunk("cue ", []byte{5, 0, 0, 0}),
+		riffChunk("data", pcm16(1, 2)),
+	)
+
+	wave, err := ReadWaveFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (wave.Bext != nil) || (len(wave.CuePoints) != 0) || (len(wave.Info) != 0) {
+		t.Errorf("expected no parsed metadata, found %+v", wave)
+	}
+	if len(wave.Chunks) != 5 {
+		t.Fatalf("expected 5 sub-chunks, found %d", len(wave.Chunks))
+	}
+	if !bytes.Equal(wave.Chunks[1].Data, []byte("IN")) {
+		t.Errorf("expected the raw LIST sub-chunk, found %q", wave.Chunks[1].Data)
+	}
+	if len(wave.Frames) != 2 {
+		t.Errorf("expected 2 frames, found %d", len(wave.Frames))
+	}
+
+	// a truncated sub-chunk after the data sub-chunk
+	file = riffFile(
+		fmtChunk(FormatPCM, 1, 8000, 2, 16),
+		riffChunk("data", pcm16(1, 2)),
+		riffChunk("iXML", []byte("<BWFXML/>")),
+	)
+	wave, err = ReadWaveFromReader(bytes.NewReader(file[:len(file)-4]), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(wave.Chunks) != 2) || (len(wave.Frames) != 2) {
+		t.Errorf("expected 2 sub-chunks and 2 frames, found %d and %d", len(wave.Chunks), len(wave.Frames))
+	}
+}
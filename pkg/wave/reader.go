@@ -12,30 +12,51 @@ import (
 
 const (
 	FormatPCM        = 0x0001
+	FormatMSADPCM    = 0x0002
 	FormatIEEEFloat  = 0x0003
 	FormatALaw       = 0x0006
 	FormatMuLaw      = 0x0007
+	FormatIMAADPCM   = 0x0011
 	FormatExtensible = 0xfffe
 )
 
+// maxMetadataSubChunkSize is the largest parsed sub-chunk that is read into memory (16 MiB). Larger fmt and fact
+// sub-chunks are rejected; larger metadata sub-chunks are skipped, like unknown sub-chunks.
+const maxMetadataSubChunkSize = 1 << 24
+
 var (
 	riffChunkId    = []byte("RIFF")
 	rifxChunkId    = []byte("RIFX")
+	rf64ChunkId    = []byte("RF64")
+	bw64ChunkId    = []byte("BW64")
 	waveFormatId   = []byte("WAVE")
 	fmtSubChunkId  = []byte("fmt ")
 	factSubChunkId = []byte("fact")
 	dataSubChunkId = []byte("data")
+	ds64SubChunkId = []byte("ds64")
+	listSubChunkId = []byte("LIST")
+	bextSubChunkId = []byte("bext")
+	cueSubChunkId  = []byte("cue ")
+	ixmlSubChunkId = []byte("iXML")
+	infoListType   = []byte("INFO")
+	adtlListType   = []byte("adtl")
+	lablSubChunkId = []byte("labl")
 )
 
 type waveReader struct {
 	reader    io.Reader
+	seeker    io.Seeker // the underlying reader, if it implements io.Seeker
 	byteOrder binary.ByteOrder
+	ds64      *Ds64
+	w64       bool
 }
 
 type sampleReader struct {
 	waveFmt        *WaveFmt
+	audioFormat    int
 	byteOrder      binary.ByteOrder
 	bytesPerSample int
+	shift          int
 	divisor        float64
 	midpoint       float64
 }
@@ -51,119 +72,366 @@ func ReadWaveFromFile(f string, headerOnly bool) (*Wave, error) {
 }
 
 func ReadWaveFromReader(reader io.Reader, headerOnly bool) (*Wave, error) {
-	chunkId, err := readBytes(reader, 4)
+	waveReader, chunks, err := newWaveReader(reader)
 	if err != nil {
 		return nil, err
 	}
 
+	return waveReader.readWave(chunks, headerOnly)
+}
+
+// newWaveReader reads the RIFF, RIFX, RF64 or BW64 header, and the ds64 sub-chunk if present.
+func newWaveReader(reader io.Reader) (*waveReader, []*Chunk, error) {
+	chunkId, err := readBytes(reader, 4)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var byteOrder binary.ByteOrder
+	is64 := false
 	if bytes.Compare(chunkId, riffChunkId) == 0 {
 		byteOrder = binary.LittleEndian
 	} else if bytes.Compare(chunkId, rifxChunkId) == 0 {
 		byteOrder = binary.BigEndian
+	} else if (bytes.Compare(chunkId, rf64ChunkId) == 0) || (bytes.Compare(chunkId, bw64ChunkId) == 0) {
+		byteOrder = binary.LittleEndian
+		is64 = true
 	} else {
-		return nil, errors.New(fmt.Sprintf("unknown chunk ID: %s", string(chunkId)))
+		return nil, nil, errors.New(fmt.Sprintf("unknown chunk ID: %s", string(chunkId)))
 	}
 
 	var chunkSize uint32
 	err = binary.Read(reader, byteOrder, &chunkSize)
-
-	if chunkSize > math.MaxInt32 {
-		return nil, errors.New(fmt.Sprintf("%s chunk size too big", string(chunkId)))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	riffReader := io.LimitReader(reader, int64(chunkSize))
+	riffReader := reader
+	if !is64 {
+		// the RF64/BW64 chunk size is in the ds64 sub-chunk; rely on EOF instead
+		riffReader = io.LimitReader(reader, int64(chunkSize))
+	}
 
 	formatId, err := readBytes(riffReader, 4)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if bytes.Compare(formatId, waveFormatId) != 0 {
-		return nil, errors.New(fmt.Sprintf("unknown format ID: %s", string(formatId)))
+		return nil, nil, errors.New(fmt.Sprintf("unknown format ID: %s", string(formatId)))
 	}
 
+	seeker, _ := reader.(io.Seeker)
 	waveReader := &waveReader{
 		reader:    riffReader,
+		seeker:    seeker,
 		byteOrder: byteOrder,
 	}
+	chunks := make([]*Chunk, 0)
+	if is64 {
+		// the ds64 sub-chunk must come first
+		subChunkId, subChunkSize, err := waveReader.readSubChunkIdAndSize()
+		if err != nil {
+			return nil, nil, err
+		}
+		if bytes.Compare(subChunkId, ds64SubChunkId) != 0 {
+			return nil, nil, errors.New(fmt.Sprintf("expected sub-chunk %s, found sub-chunk %s",
+				string(ds64SubChunkId), string(subChunkId)))
+		}
+		if subChunkSize > maxMetadataSubChunkSize {
+			return nil, nil, errors.New(fmt.Sprintf("%s sub-chunk size too big", string(subChunkId)))
+		}
+		chunkReader := io.LimitReader(waveReader.reader, int64(subChunkSize))
+		data, err := readBytes(chunkReader, int(subChunkSize))
+		if err != nil {
+			return nil, nil, err
+		}
+		ds64, err := parseDs64(data, byteOrder)
+		if err != nil {
+			return nil, nil, err
+		}
+		waveReader.ds64 = ds64
+		chunks = append(chunks, &Chunk{
+			ID:   string(subChunkId),
+			Size: int64(subChunkSize),
+			Data: data,
+		})
+		err = waveReader.skipSubChunk(chunkReader, subChunkSize)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return waveReader, chunks, nil
+}
+
+func (wr *waveReader) newWave(chunks []*Chunk) *Wave {
+	return &Wave{
+		Ds64:   wr.ds64,
+		Chunks: chunks,
+		Info:   make(map[string]string),
+	}
+}
 
-	// handle "fmt " sub-chunk
-	subChunkId, subChunkSize, err := waveReader.readSubChunkIdAndSize()
+// readWave walks the remaining sub-chunks, decoding the data sub-chunk and parsing known metadata sub-chunks.
+func (wr *waveReader) readWave(chunks []*Chunk, headerOnly bool) (*Wave, error) {
+	wave := wr.newWave(chunks)
+	dataReader, dataSize, err := wr.readSubChunksUntilData(wave, headerOnly, false)
 	if err != nil {
 		return nil, err
 	}
-	if bytes.Compare(subChunkId, fmtSubChunkId) != 0 {
-		return nil, errors.New(fmt.Sprintf("expected sub-chunk %s, found sub-chunk %s",
-			string(fmtSubChunkId), string(subChunkId)))
+	if dataReader == nil {
+		if !headerOnly {
+			return nil, errors.New(fmt.Sprintf("missing sub-chunk %s", string(dataSubChunkId)))
+		}
+		return wave, nil
+	}
+
+	if headerOnly {
+		// the metadata sub-chunks after the data sub-chunk are only read if the data can be skipped by seeking
+		if !wr.seekPastSubChunk(dataReader) {
+			return wave, nil
+		}
+	} else {
+		frames, err := wr.readPCMDataSubChunk(dataReader, dataSize, wave)
+		if err != nil {
+			return nil, err
+		}
+		wave.Frames = frames
 	}
-	waveFmt, err := waveReader.readFmtSubChunk(subChunkSize)
+	err = wr.skipSubChunk(dataReader, dataSize)
 	if err != nil {
 		return nil, err
 	}
 
-	if headerOnly {
-		return &Wave{
-			Fmt:    waveFmt,
-			Frames: nil,
-		}, nil
+	// metadata sub-chunks after the data sub-chunk
+	_, _, err = wr.readSubChunksUntilData(wave, headerOnly, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// validate supported formats
-	switch waveFmt.AudioFormat {
-	case FormatPCM:
-		break
-	case FormatIEEEFloat:
-	case FormatALaw:
-	case FormatMuLaw:
-	case FormatExtensible:
-	default:
-		return nil, errors.New(fmt.Sprintf("unsupported audio format: 0x%04x", waveFmt.AudioFormat))
+	return wave, nil
+}
+
+// readSubChunksUntilData parses sub-chunks into wave until the data sub-chunk is found, returning a reader limited to
+// its contents and its size. Returns a nil reader if the end of the file is reached. Sub-chunks that are not parsed
+// are skipped without being read into memory. If afterData is set, further data sub-chunks are skipped and trailing
+// garbage, including a truncated sub-chunk, is tolerated.
+func (wr *waveReader) readSubChunksUntilData(wave *Wave, headerOnly bool, afterData bool) (io.Reader, uint64, error) {
+	for {
+		subChunkId, subChunkSize, err := wr.readSubChunkIdAndSize()
+		if (err == io.EOF) || ((err == io.ErrUnexpectedEOF) && afterData) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		chunk := &Chunk{
+			ID:   string(subChunkId),
+			Size: int64(subChunkSize),
+		}
+		chunkReader := io.LimitReader(wr.reader, int64(subChunkSize))
+		if isParsedSubChunk(subChunkId) {
+			if subChunkSize <= maxMetadataSubChunkSize {
+				chunk.Data, err = readBytes(chunkReader, int(subChunkSize))
+				if ((err == io.EOF) || (err == io.ErrUnexpectedEOF)) && afterData {
+					// a truncated trailing sub-chunk
+					break
+				}
+				if err != nil {
+					return nil, 0, err
+				}
+			} else if (bytes.Compare(subChunkId, fmtSubChunkId) == 0) ||
+				(bytes.Compare(subChunkId, factSubChunkId) == 0) {
+				return nil, 0, errors.New(fmt.Sprintf("%s sub-chunk size too big", string(subChunkId)))
+			}
+		}
+		wave.Chunks = append(wave.Chunks, chunk)
+
+		if bytes.Compare(subChunkId, dataSubChunkId) == 0 {
+			if wave.Fmt == nil {
+				return nil, 0, errors.New(fmt.Sprintf("expected sub-chunk %s, found sub-chunk %s",
+					string(fmtSubChunkId), string(subChunkId)))
+			}
+			if !afterData {
+				return chunkReader, subChunkSize, nil
+			}
+		} else if chunk.Data != nil {
+			err = wr.parseSubChunk(wave, chunk, headerOnly)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		err = wr.skipSubChunk(chunkReader, subChunkSize)
+		if err != nil {
+			return nil, 0, err
+		}
 	}
 
-	if (waveFmt.BitsPerSample < 8) || (waveFmt.BitsPerSample > 64) || ((waveFmt.BitsPerSample % 8) != 0) {
-		return nil, errors.New(fmt.Sprintf("unsupported bits per sample: %d", waveFmt.BitsPerSample))
+	if wave.Fmt == nil {
+		return nil, 0, errors.New(fmt.Sprintf("missing sub-chunk %s", string(fmtSubChunkId)))
 	}
 
-	// read next sub-chunk
-	subChunkId, subChunkSize, err = waveReader.readSubChunkIdAndSize()
-	if err != nil {
-		return nil, err
+	return nil, 0, nil
+}
+
+// isParsedSubChunk returns whether a sub-chunk is read into memory and parsed by parseSubChunk.
+func isParsedSubChunk(subChunkId []byte) bool {
+	for _, id := range [][]byte{fmtSubChunkId, factSubChunkId, listSubChunkId, bextSubChunkId, cueSubChunkId,
+		ixmlSubChunkId} {
+		if bytes.Compare(subChunkId, id) == 0 {
+			return true
+		}
 	}
-	var waveFact *WaveFact = nil
-	if bytes.Compare(subChunkId, factSubChunkId) == 0 {
-		// handle "fact" sub-chunk
-		waveFact, err = waveReader.readFactSubChunk(subChunkSize)
+	return false
+}
+
+// parseSubChunk parses the known sub-chunks into wave. Malformed LIST, bext and cue sub-chunks are tolerated, and are
+// only in wave.Chunks.
+func (wr *waveReader) parseSubChunk(wave *Wave, chunk *Chunk, headerOnly bool) error {
+	switch chunk.ID {
+	case string(fmtSubChunkId):
+		waveFmt, err := wr.readFmtSubChunk(bytes.NewReader(chunk.Data))
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		// read next sub-chunk
-		subChunkId, subChunkSize, err = waveReader.readSubChunkIdAndSize()
+		if !headerOnly {
+			err = validateWaveFmt(waveFmt)
+			if err != nil {
+				return err
+			}
+		}
+		wave.Fmt = waveFmt
+	case string(factSubChunkId):
+		waveFact, err := wr.readFactSubChunk(bytes.NewReader(chunk.Data))
 		if err != nil {
-			return nil, err
+			return err
+		}
+		wave.Fact = waveFact
+	case string(listSubChunkId):
+		list, err := parseList(chunk.Data, wr.byteOrder)
+		if err != nil {
+			break
+		}
+		if list.Type == string(infoListType) {
+			for _, item := range list.Chunks {
+				wave.Info[item.ID] = parseString(item.Data)
+			}
+		} else if list.Type == string(adtlListType) {
+			wave.Labels = append(wave.Labels, parseLabels(list.Chunks, wr.byteOrder)...)
 		}
+	case string(bextSubChunkId):
+		bext, err := parseBext(chunk.Data, wr.byteOrder)
+		if err != nil {
+			break
+		}
+		wave.Bext = bext
+	case string(cueSubChunkId):
+		cuePoints, err := parseCuePoints(chunk.Data, wr.byteOrder)
+		if err != nil {
+			break
+		}
+		wave.CuePoints = cuePoints
+	case string(ixmlSubChunkId):
+		wave.IXML = parseString(chunk.Data)
 	}
+	return nil
+}
 
-	// expecting "data" sub-chunk
-	if bytes.Compare(subChunkId, dataSubChunkId) != 0 {
-		return nil, errors.New(fmt.Sprintf("expected sub-chunk %s, found sub-chunk %s",
-			string(dataSubChunkId), string(subChunkId)))
+// seekPastSubChunk skips the unread remainder of a sub-chunk by seeking, if the underlying reader supports it.
+// Returns false if the reader cannot seek, e.g. a pipe.
+func (wr *waveReader) seekPastSubChunk(chunkReader io.Reader) bool {
+	limitedReader, ok := chunkReader.(*io.LimitedReader)
+	if !ok || (wr.seeker == nil) {
+		return false
+	}
+	_, err := wr.seeker.Seek(limitedReader.N, io.SeekCurrent)
+	if err != nil {
+		return false
 	}
+	riffReader, ok := wr.reader.(*io.LimitedReader)
+	if ok {
+		riffReader.N -= limitedReader.N
+	}
+	limitedReader.N = 0
+	return true
+}
 
-	frames, err := waveReader.readPCMDataSubChunk(subChunkSize, waveFmt)
+// skipSubChunk discards the unread remainder of a sub-chunk, and its padding (to 2 bytes for RIFF, 8 bytes for W64).
+func (wr *waveReader) skipSubChunk(chunkReader io.Reader, subChunkSize uint64) error {
+	_, err := io.Copy(io.Discard, chunkReader)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	alignment := uint64(2)
+	if wr.w64 {
+		alignment = 8
+	}
+	padding := (alignment - (subChunkSize % alignment)) % alignment
+	if padding > 0 {
+		// the padding is frequently missing from the last sub-chunk
+		_, err = readBytes(wr.reader, int(padding))
+		if (err != nil) && (err != io.EOF) && (err != io.ErrUnexpectedEOF) {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateWaveFmt(waveFmt *WaveFmt) error {
+	// the decoders divide by both
+	if waveFmt.NumChannels < 1 {
+		return errors.New(fmt.Sprintf("invalid number of channels: %d", waveFmt.NumChannels))
+	}
+	if waveFmt.BlockAlign < 1 {
+		return errors.New(fmt.Sprintf("invalid block align: %d", waveFmt.BlockAlign))
 	}
 
-	return &Wave{
-		Fmt:    waveFmt,
-		Fact:   waveFact,
-		Frames: frames,
-	}, nil
+	sampleFormat := waveFmt.SampleFormat()
+	switch sampleFormat {
+	case FormatPCM:
+		break
+	case FormatIEEEFloat:
+	case FormatALaw:
+	case FormatMuLaw:
+	case FormatMSADPCM, FormatIMAADPCM:
+		return validateADPCMFmt(waveFmt)
+	case FormatExtensible:
+		if waveFmt.SubFormat == nil {
+			return errors.New("missing WAVE_FORMAT_EXTENSIBLE sub-format")
+		}
+		return errors.New(fmt.Sprintf("unsupported sub-format: %s", waveFmt.SubFormat))
+	default:
+		return errors.New(fmt.Sprintf("unsupported audio format: 0x%04x", sampleFormat))
+	}
+
+	if (waveFmt.BitsPerSample < 8) || (waveFmt.BitsPerSample > 64) || ((waveFmt.BitsPerSample % 8) != 0) {
+		return errors.New(fmt.Sprintf("unsupported bits per sample: %d", waveFmt.BitsPerSample))
+	}
+	if waveFmt.ValidBitsPerSample > waveFmt.BitsPerSample {
+		return errors.New(fmt.Sprintf("valid bits per sample (%d) exceeds bits per sample (%d)",
+			waveFmt.ValidBitsPerSample, waveFmt.BitsPerSample))
+	}
+	if (sampleFormat == FormatIEEEFloat) && (waveFmt.BitsPerSample != 32) && (waveFmt.BitsPerSample != 64) {
+		return errors.New(fmt.Sprintf("unsupported bits per sample for IEEE float: %d", waveFmt.BitsPerSample))
+	}
+	if ((sampleFormat == FormatALaw) || (sampleFormat == FormatMuLaw)) && (waveFmt.BitsPerSample != 8) {
+		return errors.New(fmt.Sprintf("unsupported bits per sample for G.711: %d", waveFmt.BitsPerSample))
+	}
+	if waveFmt.BlockAlign < (waveFmt.NumChannels * waveFmt.BitsPerSample / 8) {
+		return errors.New(fmt.Sprintf("invalid block align: %d", waveFmt.BlockAlign))
+	}
+
+	return nil
 }
 
-func (wr *waveReader) readSubChunkIdAndSize() ([]byte, uint32, error) {
+func (wr *waveReader) readSubChunkIdAndSize() ([]byte, uint64, error) {
+	if wr.w64 {
+		return wr.readW64SubChunkIdAndSize()
+	}
+
 	subChunkId, err := readBytes(wr.reader, 4)
 	if err != nil {
 		return nil, 0, err
@@ -174,16 +442,19 @@ func (wr *waveReader) readSubChunkIdAndSize() ([]byte, uint32, error) {
 	if err != nil {
 		return nil, 0, err
 	}
-	if subChunkSize > math.MaxInt32 {
-		return nil, 0, errors.New(fmt.Sprintf("%s sub-chunk size too big", string(subChunkId)))
+	if (subChunkSize == math.MaxUint32) && (wr.ds64 != nil) {
+		// RF64/BW64: the actual size is in the ds64 sub-chunk
+		size, ok := wr.ds64.SubChunkSize(string(subChunkId))
+		if !ok {
+			return nil, 0, errors.New(fmt.Sprintf("missing ds64 size for sub-chunk %s", string(subChunkId)))
+		}
+		return subChunkId, size, nil
 	}
 
-	return subChunkId, subChunkSize, nil
+	return subChunkId, uint64(subChunkSize), nil
 }
 
-func (wr *waveReader) readFmtSubChunk(subChunkSize uint32) (*WaveFmt, error) {
-	reader := io.LimitReader(wr.reader, int64(subChunkSize))
-
+func (wr *waveReader) readFmtSubChunk(reader io.Reader) (*WaveFmt, error) {
 	var waveFmt WaveFmt
 
 	var audioFormat uint16
@@ -247,12 +518,21 @@ func (wr *waveReader) readFmtSubChunk(subChunkSize uint32) (*WaveFmt, error) {
 		}
 	}
 
+	if (waveFmt.AudioFormat == FormatExtensible) && (len(waveFmt.ExtraParams) >= 22) {
+		waveFmt.ValidBitsPerSample = int(wr.byteOrder.Uint16(waveFmt.ExtraParams[0:2]))
+		waveFmt.ChannelMask = wr.byteOrder.Uint32(waveFmt.ExtraParams[2:6])
+		subFormat := parseGUID(waveFmt.ExtraParams[6:22], wr.byteOrder)
+		waveFmt.SubFormat = &subFormat
+	}
+	sampleFormat := waveFmt.SampleFormat()
+	if (sampleFormat == FormatMSADPCM) || (sampleFormat == FormatIMAADPCM) {
+		parseADPCMParams(&waveFmt, wr.byteOrder)
+	}
+
 	return &waveFmt, nil
 }
 
-func (wr *waveReader) readFactSubChunk(subChunkSize uint32) (*WaveFact, error) {
-	reader := io.LimitReader(wr.reader, int64(subChunkSize))
-
+func (wr *waveReader) readFactSubChunk(reader io.Reader) (*WaveFact, error) {
 	var waveFact WaveFact
 
 	var sampleLength uint32
@@ -265,50 +545,69 @@ func (wr *waveReader) readFactSubChunk(subChunkSize uint32) (*WaveFact, error) {
 	return &waveFact, nil
 }
 
-func (wr *waveReader) readPCMDataSubChunk(subChunkSize uint32, waveFmt *WaveFmt) ([][]float64, error) {
-	reader := io.LimitReader(wr.reader, int64(subChunkSize))
-
-	sr := newSampleReader(waveFmt, wr.byteOrder)
-	bytesPerSample := waveFmt.BitsPerSample / 8
-	buffer := make([]byte, waveFmt.BlockAlign)
+func (wr *waveReader) readPCMDataSubChunk(reader io.Reader, subChunkSize uint64, wave *Wave) ([][]float64, error) {
+	waveFmt := wave.Fmt
+	decoder := wr.newDecoder(wave, reader, subChunkSize)
+	buffer := make([]float64, decoderBufferFrames*waveFmt.NumChannels)
 	frames := make([][]float64, 0)
-	for true {
-		_, err := io.ReadFull(reader, buffer)
+	for {
+		n, err := decoder.ReadFrames(buffer)
+		for i := 0; i < n; i++ {
+			current := make([]float64, waveFmt.NumChannels)
+			copy(current, buffer[i*waveFmt.NumChannels:(i+1)*waveFmt.NumChannels])
+			frames = append(frames, current)
+		}
 		if err == io.EOF {
 			break
 		}
-		current := make([]float64, waveFmt.NumChannels)
-		for i := 0; i < waveFmt.NumChannels; i++ {
-			start := i * bytesPerSample
-			end := start + bytesPerSample
-			sample := buffer[start:end]
-			val, err := sr.readSample(sample)
-			if err != nil {
-				return nil, err
-			}
-			current[i] = val
+		if err != nil {
+			return nil, err
 		}
-		frames = append(frames, current)
 	}
 
 	return frames, nil
 }
 
 func newSampleReader(waveFmt *WaveFmt, byteOrder binary.ByteOrder) *sampleReader {
-	if waveFmt.BitsPerSample == 8 {
+	audioFormat := waveFmt.SampleFormat()
+	if audioFormat == FormatIEEEFloat {
 		return &sampleReader{
 			waveFmt:        waveFmt,
+			audioFormat:    audioFormat,
 			byteOrder:      byteOrder,
 			bytesPerSample: waveFmt.BitsPerSample / 8,
-			divisor:        math.MaxUint8,
-			midpoint:       128,
+			divisor:        1,
+			midpoint:       0,
+		}
+	} else if (audioFormat == FormatALaw) || (audioFormat == FormatMuLaw) {
+		return &sampleReader{
+			waveFmt:        waveFmt,
+			audioFormat:    audioFormat,
+			byteOrder:      byteOrder,
+			bytesPerSample: 1,
+			divisor:        32768,
+			midpoint:       0,
+		}
+	} else if waveFmt.BitsPerSample == 8 {
+		sampleBits := waveFmt.SampleBits()
+		return &sampleReader{
+			waveFmt:        waveFmt,
+			audioFormat:    audioFormat,
+			byteOrder:      byteOrder,
+			bytesPerSample: waveFmt.BitsPerSample / 8,
+			shift:          waveFmt.BitsPerSample - sampleBits,
+			divisor:        math.Pow(2, float64(sampleBits)) - 1,
+			midpoint:       math.Pow(2, float64(sampleBits-1)),
 		}
 	} else {
+		sampleBits := waveFmt.SampleBits()
 		return &sampleReader{
 			waveFmt:        waveFmt,
+			audioFormat:    audioFormat,
 			byteOrder:      byteOrder,
 			bytesPerSample: waveFmt.BitsPerSample / 8,
-			divisor:        math.Pow(2, float64(waveFmt.BitsPerSample-1)) - 1,
+			shift:          waveFmt.BitsPerSample - sampleBits,
+			divisor:        math.Pow(2, float64(sampleBits-1)) - 1,
 			midpoint:       0,
 		}
 	}
@@ -318,6 +617,13 @@ func (sr *sampleReader) readSample(sample []byte) (float64, error) {
 	if len(sample) != sr.bytesPerSample {
 		return 0, errors.New(fmt.Sprintf("expected %d bytes, actual %d bytes", sr.bytesPerSample, len(sample)))
 	}
+	if sr.audioFormat == FormatIEEEFloat {
+		return sr.readFloatSample(sample)
+	} else if sr.audioFormat == FormatALaw {
+		return (float64(aLawTable[sample[0]]) - sr.midpoint) / sr.divisor, nil
+	} else if sr.audioFormat == FormatMuLaw {
+		return (float64(muLawTable[sample[0]]) - sr.midpoint) / sr.divisor, nil
+	}
 	paddedBytesPerSample := sr.bytesPerSample
 	if paddedBytesPerSample > 4 && paddedBytesPerSample < 8 {
 		paddedBytesPerSample = 8
@@ -357,33 +663,44 @@ func (sr *sampleReader) readSample(sample []byte) (float64, error) {
 		if err != nil {
 			return 0, err
 		}
-		return (float64(v) - sr.midpoint) / sr.divisor, nil
+		return (float64(v>>sr.shift) - sr.midpoint) / sr.divisor, nil
 	} else if paddedBytesPerSample == 2 {
 		var v int16
 		err := binary.Read(byteBuffer, sr.byteOrder, &v)
 		if err != nil {
 			return 0, err
 		}
-		return (float64(v) - sr.midpoint) / sr.divisor, nil
+		return (float64(v>>sr.shift) - sr.midpoint) / sr.divisor, nil
 	} else if paddedBytesPerSample == 4 {
 		var v int32
 		err := binary.Read(byteBuffer, sr.byteOrder, &v)
 		if err != nil {
 			return 0, err
 		}
-		return (float64(v) - sr.midpoint) / sr.divisor, nil
+		return (float64(v>>sr.shift) - sr.midpoint) / sr.divisor, nil
 	} else if paddedBytesPerSample == 8 {
 		var v int64
 		err := binary.Read(byteBuffer, sr.byteOrder, &v)
 		if err != nil {
 			return 0, err
 		}
-		return (float64(v) - sr.midpoint) / sr.divisor, nil
+		return (float64(v>>sr.shift) - sr.midpoint) / sr.divisor, nil
 	} else {
 		return 0, errors.New(fmt.Sprintf("unsupported bits per sample: %d", sr.waveFmt.BitsPerSample))
 	}
 }
 
+// readFloatSample decodes a 32-bit or 64-bit IEEE float sample. Values are passed through unscaled.
+func (sr *sampleReader) readFloatSample(sample []byte) (float64, error) {
+	if sr.bytesPerSample == 4 {
+		return float64(math.Float32frombits(sr.byteOrder.Uint32(sample))), nil
+	} else if sr.bytesPerSample == 8 {
+		return math.Float64frombits(sr.byteOrder.Uint64(sample)), nil
+	} else {
+		return 0, errors.New(fmt.Sprintf("unsupported bits per sample for IEEE float: %d", sr.waveFmt.BitsPerSample))
+	}
+}
+
 func readBytes(reader io.Reader, len int) ([]byte, error) {
 	data := make([]byte, len)
 	_, err := io.ReadFull(reader, data)
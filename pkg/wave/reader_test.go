@@ -0,0 +1,228 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// rifxFile returns a big-endian RIFX WAVE file of a 16-byte fmt sub-chunk and a data sub-chunk.
+func rifxFile(audioFormat int, numChannels int, sampleRate int, blockAlign int, bitsPerSample int, data []byte) []byte {
+	chunk := func(id string, data []byte) []byte {
+		chunk := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+		return append(chunk, data...)
+	}
+	fmtData := binary.BigEndian.AppendUint16(nil, uint16(audioFormat))
+	fmtData = binary.BigEndian.AppendUint16(fmtData, uint16(numChannels))
+	fmtData = binary.BigEndian.AppendUint32(fmtData, uint32(sampleRate))
+	fmtData = binary.BigEndian.AppendUint32(fmtData, uint32(sampleRate*blockAlign))
+	fmtData = binary.BigEndian.AppendUint16(fmtData, uint16(blockAlign))
+	fmtData = binary.BigEndian.AppendUint16(fmtData, uint16(bitsPerSample))
+	body := append([]byte("WAVE"), chunk("fmt ", fmtData)...)
+	body = append(body, chunk("data", data)...)
+	return chunk("RIFX", body)
+}
+
+// readFrames decodes a WAVE file, returning its frames interleaved.
+func readFrames(t *testing.T, file []byte) []float64 {
+	wave, err := ReadWaveFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := make([]float64, 0)
+	for _, frame := range wave.Frames {
+		frames = append(frames, frame...)
+	}
+	return frames
+}
+
+// compareSamples compares the samples with the expected values.
+func compareSamples(t *testing.T, name string, found []float64, expected []float64) {
+	if len(found) != len(expected) {
+		t.Errorf("%s: expected %d samples, found %d", name, len(expected), len(found))
+		return
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Errorf("%s: sample %d: expected %g, found %g", name, i, expected[i], found[i])
+		}
+	}
+}
+
+func TestReadIEEEFloat(t *testing.T) {
+	// passed through unscaled, including values beyond full scale
+	values := []float64{0, 0.5, -1.25, 3, -0.1}
+	float32LE := make([]byte, 0)
+	float32BE := make([]byte, 0)
+	float64LE := make([]byte, 0)
+	float64BE := make([]byte, 0)
+	for _, val := range values {
+		float32LE = binary.LittleEndian.AppendUint32(float32LE, math.Float32bits(float32(val)))
+		float32BE = binary.BigEndian.AppendUint32(float32BE, math.Float32bits(float32(val)))
+		float64LE = binary.LittleEndian.AppendUint64(float64LE, math.Float64bits(val))
+		float64BE = binary.BigEndian.AppendUint64(float64BE, math.Float64bits(val))
+	}
+	expected32 := make([]float64, len(values))
+	for i, val := range values {
+		expected32[i] = float64(float32(val))
+	}
+
+	compareSamples(t, "RIFF float32",
+		readFrames(t, riffFile(fmtChunk(FormatIEEEFloat, 1, 8000, 4, 32), riffChunk("data", float32LE))), expected32)
+	compareSamples(t, "RIFF float64",
+		readFrames(t, riffFile(fmtChunk(FormatIEEEFloat, 1, 8000, 8, 64), riffChunk("data", float64LE))), values)
+	compareSamples(t, "RIFX float32", readFrames(t, rifxFile(FormatIEEEFloat, 1, 8000, 4, 32, float32BE)), expected32)
+	compareSamples(t, "RIFX float64", readFrames(t, rifxFile(FormatIEEEFloat, 1, 8000, 8, 64, float64BE)), values)
+	// stereo frames of float32
+	stereo := readFrames(t, riffFile(fmtChunk(FormatIEEEFloat, 2, 8000, 8, 32), riffChunk("data", float32LE[:16])))
+	compareSamples(t, "RIFF stereo float32", stereo, expected32[:4])
+}
+
+// extensibleFmtChunk returns the 40-byte fmt sub-chunk of a WAVE_FORMAT_EXTENSIBLE file.
+func extensibleFmtChunk(subFormat int, numChannels int, blockAlign int, bitsPerSample int, validBitsPerSample int,
+	channelMask uint32) []byte {
+	data := fmtChunk(FormatExtensible, numChannels, 48000, blockAlign, bitsPerSample)[8:]
+	data = binary.LittleEndian.AppendUint16(data, 22)
+	data = binary.LittleEndian.AppendUint16(data, uint16(validBitsPerSample))
+	data = binary.LittleEndian.AppendUint32(data, channelMask)
+	guid := formatGUIDBase
+	guid.Data1 = uint32(subFormat)
+	data = append(data, guidBytes(guid)...)
+	return riffChunk("fmt ", data)
+}
+
+func TestReadExtensible(t *testing.T) {
+	// 20 valid bits in 24-bit containers, with the padding bits set
+	data := make([]byte, 0)
+	for _, val := range []int32{0x7ffff, -0x80000, 1234, 0, -1, 1} {
+		data = append(data, byte(val<<4|0x0f), byte(val>>4), byte(val>>12))
+	}
+	file := riffFile(
+		extensibleFmtChunk(FormatPCM, 3, 9, 24, 20, SpeakerFrontLeft|SpeakerFrontRight|SpeakerLowFrequency),
+		riffChunk("data", data),
+	)
+	wave, err := ReadWaveFromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waveFmt := wave.Fmt
+	if (waveFmt.ValidBitsPerSample != 20) || (waveFmt.SampleBits() != 20) {
+		t.Errorf("expected 20 valid bits, found %d", waveFmt.ValidBitsPerSample)
+	}
+	if waveFmt.ChannelMask != SpeakerFrontLeft|SpeakerFrontRight|SpeakerLowFrequency {
+		t.Errorf("unexpected channel mask: 0x%x", waveFmt.ChannelMask)
+	}
+	if (waveFmt.SubFormat == nil) || (waveFmt.SubFormat.String() != "00000001-0000-0010-8000-00aa00389b71") {
+		t.Errorf("expected the PCM SubFormat, found %v", waveFmt.SubFormat)
+	}
+	if waveFmt.SampleFormat() != FormatPCM {
+		t.Errorf("expected the PCM sample format, found 0x%x", waveFmt.SampleFormat())
+	}
+	names := waveFmt.ChannelNames()
+	if (len(names) != 3) || (names[0] != "FL") || (names[1] != "FR") || (names[2] != "LFE") {
+		t.Errorf("expected channels FL, FR and LFE, found %q", names)
+	}
+	frames := make([]float64, 0)
+	for _, frame := range wave.Frames {
+		frames = append(frames, frame...)
+	}
+	compareSamples(t, "20-bit PCM", frames, []float64{1, -524288.0 / 524287, 1234.0 / 524287, 0, -1.0 / 524287,
+		1.0 / 524287})
+
+	// the SubFormat selects the decoding
+	float32LE := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.25))
+	float32LE = binary.LittleEndian.AppendUint32(float32LE, math.Float32bits(-2))
+	compareSamples(t, "float",
+		readFrames(t, riffFile(extensibleFmtChunk(FormatIEEEFloat, 2, 8, 32, 32, 0), riffChunk("data", float32LE))),
+		[]float64{0.25, -2})
+	compareSamples(t, "µ-law",
+		readFrames(t, riffFile(extensibleFmtChunk(FormatMuLaw, 1, 1, 8, 8, 0), riffChunk("data", []byte{0x80, 0xff}))),
+		[]float64{32124.0 / 32768, 0})
+}
+
+func TestChannelNames(t *testing.T) {
+	tests := []struct {
+		numChannels int
+		channelMask uint32
+		expected    []string
+	}{
+		{1, 0, []string{"FC"}},
+		{2, 0, []string{"FL", "FR"}},
+		{6, 0, []string{"FL", "FR", "FC", "LFE", "BL", "BR"}},
+		{3, 0, []string{"", "", ""}},
+		{2, SpeakerSideLeft | SpeakerSideRight, []string{"SL", "SR"}},
+		// more channels than speaker positions
+		{3, SpeakerFrontCenter | SpeakerTopBackRight, []string{"FC", "TBR", ""}},
+	}
+	for _, test := range tests {
+		waveFmt := &WaveFmt{NumChannels: test.numChannels, ChannelMask: test.channelMask}
+		names := waveFmt.ChannelNames()
+		if len(names) != len(test.expected) {
+			t.Errorf("%d channels, mask 0x%x: expected %q, found %q", test.numChannels, test.channelMask,
+				test.expected, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != test.expected[i] {
+				t.Errorf("%d channels, mask 0x%x: expected %q, found %q", test.numChannels, test.channelMask,
+					test.expected, names)
+				break
+			}
+		}
+	}
+}
+
+// rf64File returns an RF64 or BW64 file whose 32-bit sizes are all 0xffffffff, with the sizes in the ds64 sub-chunk.
+// The data sub-chunk holds data, though its size in the ds64 sub-chunk is dataSize.
+func rf64File(id string, sampleCount uint64, dataSize uint64, data []byte, trailing ...[]byte) []byte {
+	ds64 := binary.LittleEndian.AppendUint64(nil, 0)
+	ds64 = binary.LittleEndian.AppendUint64(ds64, dataSize)
+	ds64 = binary.LittleEndian.AppendUint64(ds64, sampleCount)
+	ds64 = binary.LittleEndian.AppendUint32(ds64, 0)
+	file := append([]byte(id), 0xff, 0xff, 0xff, 0xff)
+	file = append(file, "WAVE"...)
+	file = append(file, riffChunk("ds64", ds64)...)
+	file = append(file, fmtChunk(FormatPCM, 1, 8000, 2, 16)...)
+	file = append(file, "data"...)
+	file = append(file, 0xff, 0xff, 0xff, 0xff)
+	file = append(file, data...)
+	for _, subChunk := range trailing {
+		file = append(file, subChunk...)
+	}
+	return file
+}
+
+func TestReadRF64(t *testing.T) {
+	for _, id := range []string{"RF64", "BW64"} {
+		// without the size in the ds64 sub-chunk, the iXML sub-chunk would be read as samples
+		file := rf64File(id, 3, 6, pcm16(1, -2, 3), riffChunk("iXML", []byte("<BWFXML/>")))
+		wave, err := ReadWaveFromReader(bytes.NewReader(file), false)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if (wave.Ds64 == nil) || (wave.Ds64.DataSize != 6) || (wave.Ds64.SampleCount != 3) {
+			t.Errorf("%s: unexpected ds64 sub-chunk: %+v", id, wave.Ds64)
+		}
+		if len(wave.Frames) != 3 {
+			t.Errorf("%s: expected 3 frames, found %d", id, len(wave.Frames))
+		}
+		if wave.IXML != "<BWFXML/>" {
+			t.Errorf("%s: expected the iXML after the data sub-chunk, found %q", id, wave.IXML)
+		}
+	}
+
+	// a data size beyond 4 GiB
+	decoder, err := NewDecoder(bytes.NewReader(rf64File("RF64", 3<<30, 6<<30, pcm16(1, 2))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoder.NumFrames() != 3<<30 {
+		t.Errorf("expected %d frames, found %d", int64(3<<30), decoder.NumFrames())
+	}
+
+	_, err = ReadWaveFromReader(bytes.NewReader(rf64File("RF64", 3, 6, pcm16(1, -2, 3))[:12]), false)
+	if err == nil {
+		t.Errorf("expected an error without the ds64 sub-chunk")
+	}
+}
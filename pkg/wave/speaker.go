@@ -0,0 +1,68 @@
+package wave
+
+// Speaker positions used in the WAVE_FORMAT_EXTENSIBLE channel mask.
+const (
+	SpeakerFrontLeft          = 0x00001
+	SpeakerFrontRight         = 0x00002
+	SpeakerFrontCenter        = 0x00004
+	SpeakerLowFrequency       = 0x00008
+	SpeakerBackLeft           = 0x00010
+	SpeakerBackRight          = 0x00020
+	SpeakerFrontLeftOfCenter  = 0x00040
+	SpeakerFrontRightOfCenter = 0x00080
+	SpeakerBackCenter         = 0x00100
+	SpeakerSideLeft           = 0x00200
+	SpeakerSideRight          = 0x00400
+	SpeakerTopCenter          = 0x00800
+	SpeakerTopFrontLeft       = 0x01000
+	SpeakerTopFrontCenter     = 0x02000
+	SpeakerTopFrontRight      = 0x04000
+	SpeakerTopBackLeft        = 0x08000
+	SpeakerTopBackCenter      = 0x10000
+	SpeakerTopBackRight       = 0x20000
+)
+
+var speakerNames = []string{
+	"FL", "FR", "FC", "LFE", "BL", "BR", "FLC", "FRC", "BC",
+	"SL", "SR", "TC", "TFL", "TFC", "TFR", "TBL", "TBC", "TBR",
+}
+
+// SpeakerName returns the short name (e.g. "FL", "LFE") of a single speaker position, or "" if unknown.
+func SpeakerName(speaker uint32) string {
+	for i, name := range speakerNames {
+		if speaker == 1<<i {
+			return name
+		}
+	}
+	return ""
+}
+
+// SpeakerPositions returns the speaker positions in the channel mask, in channel order.
+func SpeakerPositions(channelMask uint32) []uint32 {
+	positions := make([]uint32, 0)
+	for i := 0; i < 32; i++ {
+		if (channelMask & (1 << i)) != 0 {
+			positions = append(positions, 1<<i)
+		}
+	}
+	return positions
+}
+
+func defaultChannelMask(numChannels int) uint32 {
+	switch numChannels {
+	case 1:
+		return SpeakerFrontCenter
+	case 2:
+		return SpeakerFrontLeft | SpeakerFrontRight
+	case 4:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerBackLeft | SpeakerBackRight
+	case 6:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency |
+			SpeakerBackLeft | SpeakerBackRight
+	case 8:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency |
+			SpeakerBackLeft | SpeakerBackRight | SpeakerSideLeft | SpeakerSideRight
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,118 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Sony Wave64 GUIDs. Sub-chunks that have a RIFF equivalent are mapped to the RIFF sub-chunk ID.
+var (
+	w64RiffGUID = GUID{0x66666972, 0x912e, 0x11cf, [8]byte{0xa5, 0xd6, 0x28, 0xdb, 0x04, 0xc1, 0x00, 0x00}}
+	w64WaveGUID = GUID{0x65766177, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}
+
+	w64SubChunkIds = map[GUID][]byte{
+		{0x20746d66, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}: fmtSubChunkId,
+		{0x74636166, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}: factSubChunkId,
+		{0x61746164, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}: dataSubChunkId,
+		{0x7473696c, 0x912f, 0x11cf, [8]byte{0xa5, 0xd6, 0x28, 0xdb, 0x04, 0xc1, 0x00, 0x00}}: listSubChunkId,
+		{0x74786562, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}: bextSubChunkId,
+		{0x6b6e756a, 0xacf3, 0x11d3, [8]byte{0x8c, 0xd1, 0x00, 0xc0, 0x4f, 0x8e, 0xdb, 0x8a}}: []byte("JUNK"),
+	}
+)
+
+// w64HeaderSize is the size of the GUID and 64-bit size that start every W64 chunk, included in the chunk size.
+const w64HeaderSize = 24
+
+// IsWave64 reports whether header, the start of a file, is the header of a Sony Wave64 file. At least 40 bytes are
+// needed.
+func IsWave64(header []byte) bool {
+	return (len(header) >= 40) &&
+		(parseGUID(header[0:16], binary.LittleEndian) == w64RiffGUID) &&
+		(parseGUID(header[24:40], binary.LittleEndian) == w64WaveGUID)
+}
+
+func ReadWave64FromFile(f string, headerOnly bool) (*Wave, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadWave64FromReader(file, headerOnly)
+}
+
+func ReadWave64FromReader(reader io.Reader, headerOnly bool) (*Wave, error) {
+	waveReader, err := newW64WaveReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return waveReader.readWave(make([]*Chunk, 0), headerOnly)
+}
+
+// newW64WaveReader reads the Wave64 header.
+func newW64WaveReader(reader io.Reader) (*waveReader, error) {
+	chunkId, err := readBytes(reader, 16)
+	if err != nil {
+		return nil, err
+	}
+	if parseGUID(chunkId, binary.LittleEndian) != w64RiffGUID {
+		return nil, errors.New(fmt.Sprintf("unknown chunk ID: %s", parseGUID(chunkId, binary.LittleEndian)))
+	}
+
+	var chunkSize uint64
+	err = binary.Read(reader, binary.LittleEndian, &chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if chunkSize < w64HeaderSize {
+		return nil, errors.New(fmt.Sprintf("invalid chunk size: %d", chunkSize))
+	}
+
+	riffReader := io.LimitReader(reader, int64(chunkSize-w64HeaderSize))
+
+	formatId, err := readBytes(riffReader, 16)
+	if err != nil {
+		return nil, err
+	}
+	if parseGUID(formatId, binary.LittleEndian) != w64WaveGUID {
+		return nil, errors.New(fmt.Sprintf("unknown format ID: %s", parseGUID(formatId, binary.LittleEndian)))
+	}
+
+	seeker, _ := reader.(io.Seeker)
+	return &waveReader{
+		reader:    riffReader,
+		seeker:    seeker,
+		byteOrder: binary.LittleEndian,
+		w64:       true,
+	}, nil
+}
+
+// readW64SubChunkIdAndSize reads a W64 sub-chunk header. Known GUIDs are mapped to their RIFF sub-chunk ID, others
+// are returned in their string form. The returned size excludes the header.
+func (wr *waveReader) readW64SubChunkIdAndSize() ([]byte, uint64, error) {
+	subChunkGUID, err := readBytes(wr.reader, 16)
+	if err != nil {
+		return nil, 0, err
+	}
+	guid := parseGUID(subChunkGUID, binary.LittleEndian)
+
+	var subChunkSize uint64
+	err = binary.Read(wr.reader, binary.LittleEndian, &subChunkSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if subChunkSize < w64HeaderSize {
+		return nil, 0, errors.New(fmt.Sprintf("invalid %s sub-chunk size: %d", guid, subChunkSize))
+	}
+
+	subChunkId, ok := w64SubChunkIds[guid]
+	if !ok {
+		subChunkId = []byte(guid.String())
+	}
+
+	return subChunkId, subChunkSize - w64HeaderSize, nil
+}
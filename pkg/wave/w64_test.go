@@ -0,0 +1,63 @@
+package wave
+
+import (
+	"bytes"
+	"testing"
+)
+
+func w64File(subChunks ...[]byte) []byte {
+	body := guidBytes(w64WaveGUID)
+	for _, subChunk := range subChunks {
+		body = append(body, subChunk...)
+	}
+	return w64Chunk(w64RiffGUID, body)
+}
+
+func TestWave64(t *testing.T) {
+	// the RIFF fmt sub-chunk contents, without the RIFF header
+	fmtData := fmtChunk(FormatPCM, 2, 44100, 4, 16)[8:]
+	file := w64File(
+		w64Chunk(w64SubChunkGUID("fmt "), fmtData),
+		w64Chunk(GUID{Data1: 0x12345678}, []byte{1, 2, 3}),
+		w64Chunk(w64SubChunkGUID("data"), pcm16(100, -100, 200, -200, 300, -300)),
+	)
+
+	if (string(file[0:4]) != "riff") || (string(file[24:28]) != "wave") {
+		t.Fatalf("unexpected GUIDs: % x", file[0:40])
+	}
+	if !IsWave64(file) {
+		t.Error("expected a Wave64 header")
+	}
+	if IsWave64(riffFile(fmtChunk(FormatPCM, 2, 44100, 4, 16))) || IsWave64(file[:39]) {
+		t.Error("expected no Wave64 header")
+	}
+
+	wave, err := ReadWave64FromReader(bytes.NewReader(file), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (wave.Fmt.NumChannels != 2) || (wave.Fmt.SampleRate != 44100) {
+		t.Errorf("unexpected fmt: %+v", wave.Fmt)
+	}
+	if len(wave.Frames) != 3 {
+		t.Fatalf("expected 3 frames, found %d", len(wave.Frames))
+	}
+	if (len(wave.Chunks) != 3) || (wave.Chunks[1].ID != (GUID{Data1: 0x12345678}).String()) {
+		t.Errorf("unexpected sub-chunks: %v", wave.Chunks)
+	}
+
+	decoder, err := NewWave64Decoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := make([]float64, 6)
+	n, err := decoder.ReadFrames(buffer)
+	if (err != nil) || (n != 3) {
+		t.Fatalf("expected 3 frames, read %d: %v", n, err)
+	}
+	for i, sample := range []int16{100, -100, 200, -200, 300, -300} {
+		if buffer[i] != float64(sample)/32767 {
+			t.Errorf("sample %d: expected %g, found %g", i, float64(sample)/32767, buffer[i])
+		}
+	}
+}
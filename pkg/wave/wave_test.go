@@ -0,0 +1,77 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// riffChunk returns a sub-chunk with its id and size, padded to an even size.
+func riffChunk(id string, data []byte) []byte {
+	chunk := make([]byte, 8, 8+len(data)+1)
+	copy(chunk, id)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 != 0 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// fmtChunk returns the 16-byte fmt sub-chunk of a WAVE file.
+func fmtChunk(audioFormat int, numChannels int, sampleRate int, blockAlign int, bitsPerSample int) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data[0:], uint16(audioFormat))
+	binary.LittleEndian.PutUint16(data[2:], uint16(numChannels))
+	binary.LittleEndian.PutUint32(data[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(data[8:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(data[12:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(data[14:], uint16(bitsPerSample))
+	return riffChunk("fmt ", data)
+}
+
+// riffFile returns a RIFF WAVE file of the sub-chunks.
+func riffFile(subChunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, subChunk := range subChunks {
+		body = append(body, subChunk...)
+	}
+	return riffChunk("RIFF", body)
+}
+
+// pcm16 returns the samples as 16-bit little-endian PCM.
+func pcm16(samples ...int16) []byte {
+	var buffer bytes.Buffer
+	_ = binary.Write(&buffer, binary.LittleEndian, samples)
+	return buffer.Bytes()
+}
+
+// guidBytes returns the little-endian encoding of a GUID, as in W64 files.
+func guidBytes(guid GUID) []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint32(b[0:], guid.Data1)
+	binary.LittleEndian.PutUint16(b[4:], guid.Data2)
+	binary.LittleEndian.PutUint16(b[6:], guid.Data3)
+	copy(b[8:], guid.Data4[:])
+	return b
+}
+
+// w64Chunk returns a W64 chunk with its GUID and size, padded to a multiple of 8 bytes.
+func w64Chunk(guid GUID, data []byte) []byte {
+	chunk := guidBytes(guid)
+	chunk = binary.LittleEndian.AppendUint64(chunk, uint64(w64HeaderSize+len(data)))
+	chunk = append(chunk, data...)
+	for len(chunk)%8 != 0 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// w64SubChunkGUID returns the GUID of a W64 sub-chunk with a RIFF equivalent.
+func w64SubChunkGUID(id string) GUID {
+	for guid, subChunkId := range w64SubChunkIds {
+		if string(subChunkId) == id {
+			return guid
+		}
+	}
+	panic("unknown sub-chunk " + id)
+}
@@ -1,22 +1,69 @@
 package wave
 
 type Wave struct {
-	Fmt    *WaveFmt
-	Fact   *WaveFact
-	Frames [][]float64
+	Fmt       *WaveFmt
+	Fact      *WaveFact
+	Frames    [][]float64
+	Ds64      *Ds64             // RF64/BW64 only: 64-bit sizes
+	Chunks    []*Chunk          // all sub-chunks in file order; only the parsed sub-chunks have Data
+	Info      map[string]string // LIST/INFO entries keyed by ID, e.g. "INAM", "ICMT"
+	Bext      *Bext             // Broadcast Wave Format extension
+	CuePoints []*CuePoint
+	Labels    []*Label // LIST/adtl labels and notes for cue points
+	IXML      string
 }
 
 type WaveFmt struct {
-	AudioFormat    int    // PCM = 1 (Linear Quantization), if not 1, compression was used.
-	NumChannels    int    // Mono 1, Stereo = 2, ..
-	SampleRate     int    // 44100 for CD-Quality, etc..
-	ByteRate       int    // SampleRate * NumChannels * BitsPerSample / 8
-	BlockAlign     int    // NumChannels * BitsPerSample / 8 (number of bytes per sample)
-	BitsPerSample  int    // 8 bits = 8, 16 bits = 16, .. :-)
-	ExtraParamSize int    // if not PCM, can contain extra params
-	ExtraParams    []byte // the actual extra params.
+	AudioFormat        int      // PCM = 1 (Linear Quantization), if not 1, compression was used.
+	NumChannels        int      // Mono 1, Stereo = 2, ..
+	SampleRate         int      // 44100 for CD-Quality, etc..
+	ByteRate           int      // SampleRate * NumChannels * BitsPerSample / 8
+	BlockAlign         int      // NumChannels * BitsPerSample / 8 (number of bytes per sample)
+	BitsPerSample      int      // 8 bits = 8, 16 bits = 16, .. :-)
+	ExtraParamSize     int      // if not PCM, can contain extra params
+	ExtraParams        []byte   // the actual extra params.
+	ValidBitsPerSample int      // WAVE_FORMAT_EXTENSIBLE only: bits of precision, e.g. 20 in a 24-bit container
+	ChannelMask        uint32   // WAVE_FORMAT_EXTENSIBLE only: speaker positions of the channels
+	SubFormat          *GUID    // WAVE_FORMAT_EXTENSIBLE only: the actual format of the data
+	SamplesPerBlock    int      // ADPCM only: frames in each block
+	Coefficients       [][2]int // MS ADPCM only: predictor coefficient pairs
 }
 
 type WaveFact struct {
 	SampleLength int
 }
+
+// SampleFormat returns the format the samples are encoded in, resolving WAVE_FORMAT_EXTENSIBLE to its SubFormat.
+// Returns FormatExtensible if the SubFormat is missing or not a known audio format GUID.
+func (waveFmt *WaveFmt) SampleFormat() int {
+	if (waveFmt.AudioFormat == FormatExtensible) && (waveFmt.SubFormat != nil) {
+		audioFormat, ok := waveFmt.SubFormat.AudioFormat()
+		if ok {
+			return audioFormat
+		}
+	}
+	return waveFmt.AudioFormat
+}
+
+// SampleBits returns the number of valid bits in each sample.
+func (waveFmt *WaveFmt) SampleBits() int {
+	if (waveFmt.ValidBitsPerSample > 0) && (waveFmt.ValidBitsPerSample < waveFmt.BitsPerSample) {
+		return waveFmt.ValidBitsPerSample
+	}
+	return waveFmt.BitsPerSample
+}
+
+// ChannelNames returns the speaker name (e.g. "FL", "LFE") of each channel. Channels without a known speaker
+// position have an empty name. Files without a channel mask use the default layout for their channel count.
+func (waveFmt *WaveFmt) ChannelNames() []string {
+	channelMask := waveFmt.ChannelMask
+	if channelMask == 0 {
+		channelMask = defaultChannelMask(waveFmt.NumChannels)
+	}
+	positions := SpeakerPositions(channelMask)
+	names := make([]string, waveFmt.NumChannels)
+	for i := 0; (i < len(names)) && (i < len(positions)); i++ {
+		names[i] = SpeakerName(positions[i])
+	}
+	return names
+}